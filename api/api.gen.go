@@ -6,15 +6,129 @@
 package api
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
 	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oapi-codegen/runtime"
 	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
 )
 
+// Defines values for HealthCheckResultError.
+const (
+	HealthCheckResultErrorCheckFailed   HealthCheckResultError = "check failed"
+	HealthCheckResultErrorCheckTimedOut HealthCheckResultError = "check timed out"
+)
+
+// Defines values for HealthCheckResultStatus.
+const (
+	HealthCheckResultStatusFail HealthCheckResultStatus = "fail"
+	HealthCheckResultStatusOk   HealthCheckResultStatus = "ok"
+)
+
+// Defines values for ReadinessResponseStatus.
+const (
+	ReadinessResponseStatusDegraded ReadinessResponseStatus = "degraded"
+	ReadinessResponseStatusFail     ReadinessResponseStatus = "fail"
+	ReadinessResponseStatusOk       ReadinessResponseStatus = "ok"
+)
+
+// Defines values for ListUsersParamsSort.
+const (
+	ListUsersParamsSortCreatedAt ListUsersParamsSort = "createdAt"
+	ListUsersParamsSortEmail     ListUsersParamsSort = "email"
+	ListUsersParamsSortId        ListUsersParamsSort = "id"
+	ListUsersParamsSortName      ListUsersParamsSort = "name"
+	ListUsersParamsSortUsername  ListUsersParamsSort = "username"
+)
+
+// Defines values for ListUsersParamsOrder.
+const (
+	ListUsersParamsOrderAsc  ListUsersParamsOrder = "asc"
+	ListUsersParamsOrderDesc ListUsersParamsOrder = "desc"
+)
+
+// BuildModule defines model for BuildModule.
+type BuildModule struct {
+	Path string `json:"path"`
+
+	// Replace Path and version of the module replacing this one
+	Replace *string `json:"replace,omitempty"`
+	Version string  `json:"version"`
+}
+
+// CurrentUser defines model for CurrentUser.
+type CurrentUser struct {
+	Authenticated bool    `json:"authenticated"`
+	Email         *string `json:"email,omitempty"`
+	FamilyName    *string `json:"familyName,omitempty"`
+	GivenName     *string `json:"givenName,omitempty"`
+	IsAdmin       bool    `json:"isAdmin"`
+	Name          *string `json:"name,omitempty"`
+
+	// Picture URL of the profile picture
+	Picture *string `json:"picture,omitempty"`
+
+	// Provider Login provider of the session
+	Provider *string `json:"provider,omitempty"`
+
+	// SessionValidUntil When the session expires
+	SessionValidUntil *time.Time `json:"sessionValidUntil,omitempty"`
+
+	// Timestamp When the gateway produced the profile
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+
+	// UserId User ID in Taronja Gateway
+	UserId   string `json:"userId"`
+	Username string `json:"username"`
+}
+
+// FieldError defines model for FieldError.
+type FieldError struct {
+	// Field Name of the invalid field
+	Field string `json:"field"`
+
+	// Message Why the field is invalid
+	Message string `json:"message"`
+}
+
+// HealthCheckResult defines model for HealthCheckResult.
+type HealthCheckResult struct {
+	// Cached Whether the result of a previous run was reused
+	Cached bool `json:"cached"`
+
+	// CheckedAt When the check ran
+	CheckedAt time.Time `json:"checkedAt"`
+
+	// Critical Whether a failure makes the application not ready
+	Critical bool `json:"critical"`
+
+	// Error `check failed` or `check timed out`, the details are only logged by the server
+	Error *HealthCheckResultError `json:"error,omitempty"`
+
+	// LatencyMs How long the check took, in milliseconds
+	LatencyMs float64 `json:"latencyMs"`
+
+	// Name Name of the checked component
+	Name   string                  `json:"name"`
+	Status HealthCheckResultStatus `json:"status"`
+}
+
+// HealthCheckResultError `check failed` or `check timed out`, the details are only logged by the server
+type HealthCheckResultError string
+
+// HealthCheckResultStatus defines model for HealthCheckResult.Status.
+type HealthCheckResultStatus string
+
 // HealthResponse defines model for HealthResponse.
 type HealthResponse struct {
 	// Status The health status of the application
@@ -30,11 +144,184 @@ type HealthResponse struct {
 	Version *string `json:"version,omitempty"`
 }
 
+// Problem RFC 7807 problem details returned by every failed request
+type Problem struct {
+	// Detail Explanation specific to this occurrence of the problem
+	Detail *string `json:"detail,omitempty"`
+
+	// Errors Invalid fields of a validation problem
+	Errors *[]FieldError `json:"errors,omitempty"`
+
+	// Instance Path of the request that failed
+	Instance *string `json:"instance,omitempty"`
+
+	// RequestId Request ID, also sent in the X-Request-ID header, to correlate with the logs
+	RequestId *string `json:"requestId,omitempty"`
+
+	// Status HTTP status code
+	Status int `json:"status"`
+
+	// Title Short summary of the problem type
+	Title string `json:"title"`
+
+	// Type URI identifying the problem type
+	Type string `json:"type"`
+}
+
+// ReadinessResponse defines model for ReadinessResponse.
+type ReadinessResponse struct {
+	Checks []HealthCheckResult `json:"checks"`
+
+	// Status fail when a critical check failed, degraded when a non critical check failed
+	Status ReadinessResponseStatus `json:"status"`
+
+	// Timestamp ISO 8601 timestamp when the checks were reported
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ReadinessResponseStatus fail when a critical check failed, degraded when a non critical check failed
+type ReadinessResponseStatus string
+
+// User defines model for User.
+type User struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Email     string    `json:"email"`
+
+	// ExternalId Taronja Gateway user ID, only for users provisioned from a gateway session
+	ExternalId *string `json:"externalId,omitempty"`
+	Id         int64   `json:"id"`
+	Name       string  `json:"name"`
+
+	// Provider Login provider the user was provisioned from
+	Provider  *string   `json:"provider,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Username  string    `json:"username"`
+}
+
+// UserCreate defines model for UserCreate.
+type UserCreate struct {
+	Email    string  `json:"email"`
+	Name     *string `json:"name,omitempty"`
+	Username string  `json:"username"`
+}
+
+// UserPage defines model for UserPage.
+type UserPage struct {
+	Items []User `json:"items"`
+
+	// NextCursor Cursor of the following page, absent on the last page and on pages requested with an offset
+	NextCursor *string `json:"nextCursor,omitempty"`
+
+	// Total Number of users matching the filters, across all pages
+	Total int64 `json:"total"`
+}
+
+// UserUpdate defines model for UserUpdate.
+type UserUpdate struct {
+	Email    *string `json:"email,omitempty"`
+	Name     *string `json:"name,omitempty"`
+	Username *string `json:"username,omitempty"`
+}
+
+// VersionResponse defines model for VersionResponse.
+type VersionResponse struct {
+	Arch string `json:"arch"`
+
+	// Commit VCS revision the binary was built from
+	Commit string `json:"commit"`
+
+	// Date Build date, or the commit date for local builds
+	Date string `json:"date"`
+
+	// Deps Modules the binary was built with
+	Deps []BuildModule `json:"deps"`
+
+	// Dirty Whether the working tree had uncommitted changes
+	Dirty     bool   `json:"dirty"`
+	GoVersion string `json:"goVersion"`
+	Os        string `json:"os"`
+
+	// Version Release version, or dev for local builds
+	Version string `json:"version"`
+}
+
+// ListUsersParams defines parameters for ListUsers.
+type ListUsersParams struct {
+	// Email Case-insensitive substring of the email
+	Email *string `form:"email,omitempty" json:"email,omitempty"`
+
+	// Username Case-insensitive substring of the username
+	Username *string `form:"username,omitempty" json:"username,omitempty"`
+
+	// Name Case-insensitive substring of the name
+	Name *string `form:"name,omitempty" json:"name,omitempty"`
+
+	// CreatedFrom Only users created at or after this time
+	CreatedFrom *time.Time `form:"createdFrom,omitempty" json:"createdFrom,omitempty"`
+
+	// CreatedTo Only users created before this time
+	CreatedTo *time.Time `form:"createdTo,omitempty" json:"createdTo,omitempty"`
+
+	// Sort Field to sort by, ties are broken by id
+	Sort *ListUsersParamsSort `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// Order Sort direction
+	Order *ListUsersParamsOrder `form:"order,omitempty" json:"order,omitempty"`
+
+	// Limit Page size
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Offset Number of users to skip, not allowed together with cursor
+	Offset *int `form:"offset,omitempty" json:"offset,omitempty"`
+
+	// Cursor nextCursor of the previous page
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+}
+
+// ListUsersParamsSort defines parameters for ListUsers.
+type ListUsersParamsSort string
+
+// ListUsersParamsOrder defines parameters for ListUsers.
+type ListUsersParamsOrder string
+
+// CreateUserJSONRequestBody defines body for CreateUser for application/json ContentType.
+type CreateUserJSONRequestBody = UserCreate
+
+// UpdateUserJSONRequestBody defines body for UpdateUser for application/json ContentType.
+type UpdateUserJSONRequestBody = UserUpdate
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
 	// Health check endpoint
 	// (GET /api/health)
 	HealthCheck(w http.ResponseWriter, r *http.Request)
+	// Liveness probe
+	// (GET /api/health/live)
+	HealthLive(w http.ResponseWriter, r *http.Request)
+	// Readiness probe
+	// (GET /api/health/ready)
+	HealthReady(w http.ResponseWriter, r *http.Request)
+	// Current user
+	// (GET /api/me)
+	GetMe(w http.ResponseWriter, r *http.Request)
+	// List users
+	// (GET /api/users)
+	ListUsers(w http.ResponseWriter, r *http.Request, params ListUsersParams)
+	// Create a user
+	// (POST /api/users)
+	CreateUser(w http.ResponseWriter, r *http.Request)
+	// Delete a user
+	// (DELETE /api/users/{id})
+	DeleteUser(w http.ResponseWriter, r *http.Request, id int64)
+	// Get a user
+	// (GET /api/users/{id})
+	GetUser(w http.ResponseWriter, r *http.Request, id int64)
+	// Update a user
+	// (PATCH /api/users/{id})
+	UpdateUser(w http.ResponseWriter, r *http.Request, id int64)
+	// Build information
+	// (GET /api/version)
+	GetVersion(w http.ResponseWriter, r *http.Request)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -60,6 +347,250 @@ func (siw *ServerInterfaceWrapper) HealthCheck(w http.ResponseWriter, r *http.Re
 	handler.ServeHTTP(w, r)
 }
 
+// HealthLive operation middleware
+func (siw *ServerInterfaceWrapper) HealthLive(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.HealthLive(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// HealthReady operation middleware
+func (siw *ServerInterfaceWrapper) HealthReady(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.HealthReady(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetMe operation middleware
+func (siw *ServerInterfaceWrapper) GetMe(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetMe(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListUsers operation middleware
+func (siw *ServerInterfaceWrapper) ListUsers(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListUsersParams
+
+	// ------------- Optional query parameter "email" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "email", r.URL.Query(), &params.Email)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "email", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "username" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "username", r.URL.Query(), &params.Username)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "username", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "name" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "name", r.URL.Query(), &params.Name)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "name", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "createdFrom" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "createdFrom", r.URL.Query(), &params.CreatedFrom)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "createdFrom", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "createdTo" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "createdTo", r.URL.Query(), &params.CreatedTo)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "createdTo", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "sort" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "sort", r.URL.Query(), &params.Sort)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sort", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "order" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "order", r.URL.Query(), &params.Order)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "order", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "offset" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "offset", r.URL.Query(), &params.Offset)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "offset", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListUsers(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateUser operation middleware
+func (siw *ServerInterfaceWrapper) CreateUser(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateUser(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteUser operation middleware
+func (siw *ServerInterfaceWrapper) DeleteUser(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteUser(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetUser operation middleware
+func (siw *ServerInterfaceWrapper) GetUser(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetUser(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UpdateUser operation middleware
+func (siw *ServerInterfaceWrapper) UpdateUser(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpdateUser(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetVersion operation middleware
+func (siw *ServerInterfaceWrapper) GetVersion(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetVersion(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 type UnescapedCookieParamError struct {
 	ParamName string
 	Err       error
@@ -181,10 +712,21 @@ func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.H
 	}
 
 	m.HandleFunc("GET "+options.BaseURL+"/api/health", wrapper.HealthCheck)
+	m.HandleFunc("GET "+options.BaseURL+"/api/health/live", wrapper.HealthLive)
+	m.HandleFunc("GET "+options.BaseURL+"/api/health/ready", wrapper.HealthReady)
+	m.HandleFunc("GET "+options.BaseURL+"/api/me", wrapper.GetMe)
+	m.HandleFunc("GET "+options.BaseURL+"/api/users", wrapper.ListUsers)
+	m.HandleFunc("POST "+options.BaseURL+"/api/users", wrapper.CreateUser)
+	m.HandleFunc("DELETE "+options.BaseURL+"/api/users/{id}", wrapper.DeleteUser)
+	m.HandleFunc("GET "+options.BaseURL+"/api/users/{id}", wrapper.GetUser)
+	m.HandleFunc("PATCH "+options.BaseURL+"/api/users/{id}", wrapper.UpdateUser)
+	m.HandleFunc("GET "+options.BaseURL+"/api/version", wrapper.GetVersion)
 
 	return m
 }
 
+type ProblemApplicationProblemPlusJSONResponse Problem
+
 type HealthCheckRequestObject struct {
 }
 
@@ -201,30 +743,357 @@ func (response HealthCheck200JSONResponse) VisitHealthCheckResponse(w http.Respo
 	return json.NewEncoder(w).Encode(response)
 }
 
-// StrictServerInterface represents all server handlers.
-type StrictServerInterface interface {
-	// Health check endpoint
-	// (GET /api/health)
-	HealthCheck(ctx context.Context, request HealthCheckRequestObject) (HealthCheckResponseObject, error)
+type HealthCheckdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
 }
 
-type StrictHandlerFunc = strictnethttp.StrictHTTPHandlerFunc
-type StrictMiddlewareFunc = strictnethttp.StrictHTTPMiddlewareFunc
+func (response HealthCheckdefaultApplicationProblemPlusJSONResponse) VisitHealthCheckResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
 
-type StrictHTTPServerOptions struct {
-	RequestErrorHandlerFunc  func(w http.ResponseWriter, r *http.Request, err error)
-	ResponseErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+	return json.NewEncoder(w).Encode(response.Body)
 }
 
-func NewStrictHandler(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc) ServerInterface {
-	return &strictHandler{ssi: ssi, middlewares: middlewares, options: StrictHTTPServerOptions{
-		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		},
-		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		},
-	}}
+type HealthLiveRequestObject struct {
+}
+
+type HealthLiveResponseObject interface {
+	VisitHealthLiveResponse(w http.ResponseWriter) error
+}
+
+type HealthLive200JSONResponse HealthResponse
+
+func (response HealthLive200JSONResponse) VisitHealthLiveResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type HealthLivedefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response HealthLivedefaultApplicationProblemPlusJSONResponse) VisitHealthLiveResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type HealthReadyRequestObject struct {
+}
+
+type HealthReadyResponseObject interface {
+	VisitHealthReadyResponse(w http.ResponseWriter) error
+}
+
+type HealthReady200JSONResponse ReadinessResponse
+
+func (response HealthReady200JSONResponse) VisitHealthReadyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type HealthReady503JSONResponse ReadinessResponse
+
+func (response HealthReady503JSONResponse) VisitHealthReadyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type HealthReadydefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response HealthReadydefaultApplicationProblemPlusJSONResponse) VisitHealthReadyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetMeRequestObject struct {
+}
+
+type GetMeResponseObject interface {
+	VisitGetMeResponse(w http.ResponseWriter) error
+}
+
+type GetMe200JSONResponse CurrentUser
+
+func (response GetMe200JSONResponse) VisitGetMeResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetMe401ApplicationProblemPlusJSONResponse struct {
+	ProblemApplicationProblemPlusJSONResponse
+}
+
+func (response GetMe401ApplicationProblemPlusJSONResponse) VisitGetMeResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetMedefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetMedefaultApplicationProblemPlusJSONResponse) VisitGetMeResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type ListUsersRequestObject struct {
+	Params ListUsersParams
+}
+
+type ListUsersResponseObject interface {
+	VisitListUsersResponse(w http.ResponseWriter) error
+}
+
+type ListUsers200JSONResponse UserPage
+
+func (response ListUsers200JSONResponse) VisitListUsersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListUsersdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response ListUsersdefaultApplicationProblemPlusJSONResponse) VisitListUsersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type CreateUserRequestObject struct {
+	Body *CreateUserJSONRequestBody
+}
+
+type CreateUserResponseObject interface {
+	VisitCreateUserResponse(w http.ResponseWriter) error
+}
+
+type CreateUser201JSONResponse User
+
+func (response CreateUser201JSONResponse) VisitCreateUserResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateUserdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response CreateUserdefaultApplicationProblemPlusJSONResponse) VisitCreateUserResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type DeleteUserRequestObject struct {
+	Id int64 `json:"id"`
+}
+
+type DeleteUserResponseObject interface {
+	VisitDeleteUserResponse(w http.ResponseWriter) error
+}
+
+type DeleteUser204Response struct {
+}
+
+func (response DeleteUser204Response) VisitDeleteUserResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteUserdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response DeleteUserdefaultApplicationProblemPlusJSONResponse) VisitDeleteUserResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetUserRequestObject struct {
+	Id int64 `json:"id"`
+}
+
+type GetUserResponseObject interface {
+	VisitGetUserResponse(w http.ResponseWriter) error
+}
+
+type GetUser200JSONResponse User
+
+func (response GetUser200JSONResponse) VisitGetUserResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetUserdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetUserdefaultApplicationProblemPlusJSONResponse) VisitGetUserResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type UpdateUserRequestObject struct {
+	Id   int64 `json:"id"`
+	Body *UpdateUserJSONRequestBody
+}
+
+type UpdateUserResponseObject interface {
+	VisitUpdateUserResponse(w http.ResponseWriter) error
+}
+
+type UpdateUser200JSONResponse User
+
+func (response UpdateUser200JSONResponse) VisitUpdateUserResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateUserdefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response UpdateUserdefaultApplicationProblemPlusJSONResponse) VisitUpdateUserResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetVersionRequestObject struct {
+}
+
+type GetVersionResponseObject interface {
+	VisitGetVersionResponse(w http.ResponseWriter) error
+}
+
+type GetVersion200JSONResponse VersionResponse
+
+func (response GetVersion200JSONResponse) VisitGetVersionResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetVersion401ApplicationProblemPlusJSONResponse struct {
+	ProblemApplicationProblemPlusJSONResponse
+}
+
+func (response GetVersion401ApplicationProblemPlusJSONResponse) VisitGetVersionResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetVersiondefaultApplicationProblemPlusJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response GetVersiondefaultApplicationProblemPlusJSONResponse) VisitGetVersionResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+// StrictServerInterface represents all server handlers.
+type StrictServerInterface interface {
+	// Health check endpoint
+	// (GET /api/health)
+	HealthCheck(ctx context.Context, request HealthCheckRequestObject) (HealthCheckResponseObject, error)
+	// Liveness probe
+	// (GET /api/health/live)
+	HealthLive(ctx context.Context, request HealthLiveRequestObject) (HealthLiveResponseObject, error)
+	// Readiness probe
+	// (GET /api/health/ready)
+	HealthReady(ctx context.Context, request HealthReadyRequestObject) (HealthReadyResponseObject, error)
+	// Current user
+	// (GET /api/me)
+	GetMe(ctx context.Context, request GetMeRequestObject) (GetMeResponseObject, error)
+	// List users
+	// (GET /api/users)
+	ListUsers(ctx context.Context, request ListUsersRequestObject) (ListUsersResponseObject, error)
+	// Create a user
+	// (POST /api/users)
+	CreateUser(ctx context.Context, request CreateUserRequestObject) (CreateUserResponseObject, error)
+	// Delete a user
+	// (DELETE /api/users/{id})
+	DeleteUser(ctx context.Context, request DeleteUserRequestObject) (DeleteUserResponseObject, error)
+	// Get a user
+	// (GET /api/users/{id})
+	GetUser(ctx context.Context, request GetUserRequestObject) (GetUserResponseObject, error)
+	// Update a user
+	// (PATCH /api/users/{id})
+	UpdateUser(ctx context.Context, request UpdateUserRequestObject) (UpdateUserResponseObject, error)
+	// Build information
+	// (GET /api/version)
+	GetVersion(ctx context.Context, request GetVersionRequestObject) (GetVersionResponseObject, error)
+}
+
+type StrictHandlerFunc = strictnethttp.StrictHTTPHandlerFunc
+type StrictMiddlewareFunc = strictnethttp.StrictHTTPMiddlewareFunc
+
+type StrictHTTPServerOptions struct {
+	RequestErrorHandlerFunc  func(w http.ResponseWriter, r *http.Request, err error)
+	ResponseErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+func NewStrictHandler(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares, options: StrictHTTPServerOptions{
+		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		},
+		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		},
+	}}
 }
 
 func NewStrictHandlerWithOptions(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc, options StrictHTTPServerOptions) ServerInterface {
@@ -260,3 +1129,371 @@ func (sh *strictHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
 	}
 }
+
+// HealthLive operation middleware
+func (sh *strictHandler) HealthLive(w http.ResponseWriter, r *http.Request) {
+	var request HealthLiveRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.HealthLive(ctx, request.(HealthLiveRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "HealthLive")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(HealthLiveResponseObject); ok {
+		if err := validResponse.VisitHealthLiveResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// HealthReady operation middleware
+func (sh *strictHandler) HealthReady(w http.ResponseWriter, r *http.Request) {
+	var request HealthReadyRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.HealthReady(ctx, request.(HealthReadyRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "HealthReady")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(HealthReadyResponseObject); ok {
+		if err := validResponse.VisitHealthReadyResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetMe operation middleware
+func (sh *strictHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	var request GetMeRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetMe(ctx, request.(GetMeRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetMe")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetMeResponseObject); ok {
+		if err := validResponse.VisitGetMeResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListUsers operation middleware
+func (sh *strictHandler) ListUsers(w http.ResponseWriter, r *http.Request, params ListUsersParams) {
+	var request ListUsersRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListUsers(ctx, request.(ListUsersRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListUsers")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListUsersResponseObject); ok {
+		if err := validResponse.VisitListUsersResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// CreateUser operation middleware
+func (sh *strictHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var request CreateUserRequestObject
+
+	var body CreateUserJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CreateUser(ctx, request.(CreateUserRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CreateUser")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CreateUserResponseObject); ok {
+		if err := validResponse.VisitCreateUserResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteUser operation middleware
+func (sh *strictHandler) DeleteUser(w http.ResponseWriter, r *http.Request, id int64) {
+	var request DeleteUserRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteUser(ctx, request.(DeleteUserRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteUser")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteUserResponseObject); ok {
+		if err := validResponse.VisitDeleteUserResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetUser operation middleware
+func (sh *strictHandler) GetUser(w http.ResponseWriter, r *http.Request, id int64) {
+	var request GetUserRequestObject
+
+	request.Id = id
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetUser(ctx, request.(GetUserRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetUser")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetUserResponseObject); ok {
+		if err := validResponse.VisitGetUserResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// UpdateUser operation middleware
+func (sh *strictHandler) UpdateUser(w http.ResponseWriter, r *http.Request, id int64) {
+	var request UpdateUserRequestObject
+
+	request.Id = id
+
+	var body UpdateUserJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.UpdateUser(ctx, request.(UpdateUserRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "UpdateUser")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(UpdateUserResponseObject); ok {
+		if err := validResponse.VisitUpdateUserResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetVersion operation middleware
+func (sh *strictHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	var request GetVersionRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetVersion(ctx, request.(GetVersionRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetVersion")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetVersionResponseObject); ok {
+		if err := validResponse.VisitGetVersionResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// Base64 encoded, gzipped, json marshaled Swagger object
+var swaggerSpec = []string{
+
+	"H4sIAAAAAAAC/8RaX3PbuBH/KjtsH9opLVOy8uf81JxzybmTy2UcJ+3cOTMHEksJMQnwANCyeuPv3lmA",
+	"lEgRlOXEuT5JJMHdxf7fH/hHlKmyUhKlNdHpH5FGUylp0F280yotsKS/mZIWpaW/rKoKkTErlDyu/Ip/",
+	"fDZK0jOTLbFk9O+vGvPoNPrL8Zb+sX9qjlu6d3d3ccTRZFpURC46jX7QWmlopQBm4OLVGTx7njyDhhdw",
+	"tEwUJqKXG4rE8PtaFPwnxesC6bLSqkJthd9JxeySfvGWlRUtiBZKlxOhjuk3iiO7ruiusVrIRXQXRxqr",
+	"gmWOVF/Cd8wugUkON6iNUBJUDnaJUDrW4N8TcgF2KQwoiSHqzbt9kW6mk5PpZDpc78T5vRYaeXT6q9/M",
+	"lsanzXqVfsbMEv2zWmuU9oNBPVQGq+0SpSUbIu+JYHWNG2qpUgUySeSwZKLoC/uZSfxncznJVFCHOStF",
+	"sX7LSuy/+1IFlbIQNyiHq//FwjoU5gUvRV+HOStMcAcySBZGJKlEZmsdMP6HizetvSutclEgtGtDZLS6",
+	"ERz1kM4btRAS2uctSYPGWTTu+alaFEHqzeqPrBD8g7SiGLL59xJllzLgbSU0miiOcqVLZqPTiDOLR1aU",
+	"QR5031hWVntoL5jFFVvTbnidIe9q52BGtUF9zgMKN6jh/CUICZdMK/mZwWvPr6clNk1n2ckY4aHxPwd9",
+	"aifO+nGykbFDc+uEoSB8JbDgLqENYzCnZ8Ptkve33iDkDZkW/NLubn04BjZbojFsgSFjrR1NRwuEaYn3",
+	"yJa1sZAiMPCMHRtgnGs05l5ttWK2MoQ08iOywi7PlphdX6CpCztUTMayJfKgu9klarcL7d4lPTGoNN4I",
+	"VRvQtYQVM6CxNs5cwySQEWPkL+web3ZrQDN5sOtmWpCPFOMyM8iZKGqNULJrNI5Pp4qCVBY0Mr4OSo2t",
+	"B/WJ/+YlJcrIfwOloblDcnJQtf0tdpyaeglMIyhZrKFQiwVySNdNatA3qKM4QlmXZMgu3SiOdohGn7ou",
+	"s7N2oJuCWZTZ+iczlP9HtYJCyUVH61ap65hCvRRFIQxmSnLTddHpZPakaxZVp93cKOsyRd1N9+PR1fgC",
+	"bNqTLh8yOEuZCeddy2ztNtRqTF2Ts1BM9pSjroev7wRNk0Uakh1f6qqu67hxGyDj4XXRNE/D2NpK3tfL",
+	"5RJh6d4Fv6RVUsdJo/t2trdYnL//GZ4/TaawWQOrNuIazt4FKIIr1GRi7GenWTI7OZomR8n0cjo7TZLT",
+	"JPnl8PJSuSf7nbAbkktmIEWUlFYkEemJsjxJyvkTc09n12f1okO9XdQlOp0kk+Reh9l4ylbZIU/odO59",
+	"Kca6adBoay19WsAb1OsmqoG4o7FRvONM/sUhhx9uq4JJv09TYSZykYFVTTucZa4zzbDTRjlJu6qg+uqS",
+	"Yq5qGcwrLicGPPm8WzONrxDujpdny0xYLM19c0qngN9thGBaszVdC2ksk6MjQrO/Rn1gl8xuE+V2r8es",
+	"Ese0YXM8n4UHEUcg1BxdNLTPX8bACqPAoLSUP4nxf46ax0fnLynGOOqY7JAprZGSC6yEXbqlhVr00mx0",
+	"ks/S59kUj56xOT+ap8/w6Ls8YUfTbMZPcJ4/YU/T/alxJ8wuL9+1qSVTHLvM5sl8Q0lIiwufwa2wRUC1",
+	"75dKWzB1WTK93vEhcFS6+3irLLwa8yF/Y9jhn4PgKK3I10Iu9tNnqartaVoweX+qb172+9qoKhS8F8i4",
+	"kGjMeCZ3ydL9O8iRh31XwJ/HjEdO67M1g7Y+Qbfwx8BxoRlH3i6TSoaXdtoMV0PaFw+un19cZbzGYIXa",
+	"zehK28erL/fn6Li1WMje4Sk908hs26weVuW+cEzHW4tasiKUYnaGLqj9PBb7TjJX2t0xfpSlmoYccq1K",
+	"YJu5cDvVDjiLPvwwn3X0LaR9Oo9CqeGBs/yhQzh5idue60F29nPgTF5X/KFG+4oB1c9vzTDYGUqbn60H",
+	"dQUb88Azt3roh1/oVA8z0lcoYbD/sQ2+Y4vA9jb586BESnRCuVPirT2rtQkNav5+W6tyVRRqRZWlYguM",
+	"gaWuaCufpQpmrHvgEEbqWNgCTdtEIPc1mxHomBu0wfyobGgWfetmI5LCB2zJbLZsC1wuCovaxMAyrYwB",
+	"VhSec3RAQO46pdNjK8eYMT44f9zjbaMOtdd3hq4y4P7R997jtZXpbAcrZiXv7nzLOlNlKQJowsez90C4",
+	"BDFyCk6FpH6FMktai8IOc0oynZ3Mnzx99vw7lmYc87HrkBitKvtCOEAc6FlM6IArgk5ed8/l7kJRgSaJ",
+	"uNkthk+PkulRMrtMTk6T+Wny5JcgZ6wCHYNH4U145+TBhzbgXUw/EHVcaLveDxWtlL52Xq4RYck41NIr",
+	"gYIpWzLpnXyIuizUxxBGv1DTyWw+CXbqyvTXFkLWtw+aES+wQGawnQ+d3Tje7LfVdDI/YHDcjpyN07bq",
+	"a9zHiR975+/uvrHxMI7v3AiUq8Ck++58M/n5uStv/O/1z5fv4RLLys0ffXShafgjt+bFu/POCYc7GqHp",
+	"mJRcoWSVoCGlGZjpOMRp3o1SHk2gywXakIZpzDVd4GEf5EF5wf2n3qgLX0Zx/6hsliR7jskedjy2A+ME",
+	"Tsl+7EImps4yNCavCx+ROWuA1RCPjdCdQ7g4auapXcooeaWEtG5NR7vHhbjBe1U8SxJYLUWB7QxFUoIw",
+	"wFK6pzzy2NY2M4G3CjhWKDnhXrSwgb3iK2kUKJ0t0VjNrNIGNBrLtB3gNq4tdU2/sETC2Dq7jh2YsBkF",
+	"WnCPnnO1kpOrMVO/oX3+Xy19ubM/Up/T/tebmjYnySQ03uLAxh6QHjVy3QSRxoUwFjXyHpBn4G+tmmPw",
+	"243biSAGLsw1mIplGMNkMvm763YKYayjeSWbkHR3PQxK4YksW4KSOIHWxZ4kJ3smUxODUVAoxiFlBZMZ",
+	"anIIVV1JrWrrywLLN/gUQgvpTOCFo0BLArOsnx+HOD4zm0n4SrpObZYkEzhrXqLB28PwHsN1aZFBjito",
+	"sO5xT7xojge+mSsOQYfDvNG5SQyVMkakxXqjAHLQJ8nJnyvfixHU4euDZcN+N1pKPKjWuMGyPXrwh/Pt",
+	"YBxTW5YK2TbjzW3yjhXT3EGyV3J3EN+Ad45yexjdcGgCLeROr9H+9E1zWvfTgxEXahVQN9PUPJk+wC5f",
+	"bcuzLvs4uj1q+6QjrXy/3zv43Zia1pt7rc38/LZ30JrAO7YQklkEFK5XdeYsRCnssR/trqTShMVXzJj2",
+	"7e2UuUU+m9NPx5MZUq1ROmT4N8I4oxjXMmlWonXb+XUwrzKDR0IalEZYcYNg6tQ3lS3XduQWtPz3GvW6",
+	"BR22Z9NbZxn0pQ9n2D1wD/DsPH5UtntYPpzdz9SZeJdogBlglhp8lls3rAgDVowybN555efGLd/D8MkD",
+	"hEkxVxoPleNSPYIU7nzFdYJKW0jXMViBvkKmWl2jpAAQfEQSeqknxCYtRKILNB+Mkn06QOT3JCkXGrNm",
+	"QghJpjRHPSIaM1lHNn9FLA7iThAWGPHfMeO4BBJmPEviqGS3oiTG04SuhGyuQpjOffgRWe1aVL61ZoRp",
+	"IZlygdt05nPRmI5aAGsr60ag5BCB7kmGY+7byjQeuZ++YXHc4JDB5qVXOB6lwze2IdYrcxXqUrgmg/K/",
+	"iwlzSo1c9IkAc2Ucx3758PDwB18ym5nte8XXj6oaz8QrZwteWF3j3cAo00flHDII3W/T42O0HY4SsEDf",
+	"ETTISguL0ad++3H8h+B3PrALtDi000t3f2OnnsrmI9/XeVqPsUfP/eF7jNueatCshneSfHPjXzZtxyMo",
+	"5TXawzWyDcN9PVrzWWSb5Jovgpsc5wpeP3yCtboF9PcWgk8kifWI+I4MDsI32+8KaTbC7icIqaLRkP4p",
+	"qgq+sheYW0JhHfbKB22qp/qN84xnclie+ZNczZ/PPZbL+Q1+ea7pINT3jrYbsLp76hF7pNofNRCOQ5Av",
+	"ed7muxj/XVVzPhA3eIkDiVVL0r24/bbegLA7BwkTOLfQbI1Gr3Z2TjFjtcEruX27JWpgiUVF/Usu5AJ1",
+	"pYW0cC3VSsJNXUjULBWFsALNyPy8hca/mavsHlIFvMYf7wjpw5nu/fmT9FCGQ8bpu7v/DQC2HTTOdjIA",
+	"AA==",
+}
+
+// GetSwagger returns the content of the embedded swagger specification file
+// or error if failed to decode
+func decodeSpec() ([]byte, error) {
+	zipped, err := base64.StdEncoding.DecodeString(strings.Join(swaggerSpec, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error base64 decoding spec: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(zipped))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var rawSpec = decodeSpecCached()
+
+// a naive cached of a decoded swagger spec
+func decodeSpecCached() func() ([]byte, error) {
+	data, err := decodeSpec()
+	return func() ([]byte, error) {
+		return data, err
+	}
+}
+
+// Constructs a synthetic filesystem for resolving external references when loading openapi specifications.
+func PathToRawSpec(pathToFile string) map[string]func() ([]byte, error) {
+	res := make(map[string]func() ([]byte, error))
+	if len(pathToFile) > 0 {
+		res[pathToFile] = rawSpec
+	}
+
+	return res
+}
+
+// GetSwagger returns the Swagger specification corresponding to the generated code
+// in this file. The external references of Swagger specification are resolved.
+// The logic of resolving external references is tightly connected to "import-mapping" feature.
+// Externally referenced files must be embedded in the corresponding golang packages.
+// Urls can be supported but this task was out of the scope.
+func GetSwagger() (swagger *openapi3.T, err error) {
+	resolvePath := PathToRawSpec("")
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(loader *openapi3.Loader, url *url.URL) ([]byte, error) {
+		pathToFile := url.String()
+		pathToFile = path.Clean(pathToFile)
+		getSpec, ok := resolvePath[pathToFile]
+		if !ok {
+			err1 := fmt.Errorf("path not found: %s", pathToFile)
+			return nil, err1
+		}
+		return getSpec()
+	}
+	var specData []byte
+	specData, err = rawSpec()
+	if err != nil {
+		return
+	}
+	swagger, err = loader.LoadFromData(specData)
+	if err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,107 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Ldflags are the values injected with -X by GoReleaser into main, the placeholders when built otherwise
+type Ldflags struct {
+	Version string
+	Commit  string
+	Date    string
+	OS      string
+	Arch    string
+}
+
+// Placeholders of the main variables when they are not injected
+const (
+	placeholderVersion = "dev"
+	placeholderCommit  = "none"
+	placeholderUnknown = "unknown"
+)
+
+// Module is a module the binary was built with
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	// Replace is the path and version of the module replacing this one, if any
+	Replace string `json:"replace,omitempty"`
+}
+
+// Info describes the running binary
+type Info struct {
+	Version string `json:"version"`
+	// Commit is the VCS revision the binary was built from
+	Commit string `json:"commit"`
+	// Dirty is true when the working tree had uncommitted changes
+	Dirty     bool     `json:"dirty"`
+	Date      string   `json:"date"`
+	OS        string   `json:"os"`
+	Arch      string   `json:"arch"`
+	GoVersion string   `json:"goVersion"`
+	Deps      []Module `json:"deps"`
+}
+
+// New returns the build information of the running binary. The injected ldflags win, and the
+// values left as placeholders are read from the information embedded by the Go toolchain, so
+// plain go build and go install binaries report their VCS revision and module version too.
+func New(ldflags Ldflags) Info {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		buildInfo = nil
+	}
+	return newInfo(ldflags, buildInfo)
+}
+
+func newInfo(ldflags Ldflags, buildInfo *debug.BuildInfo) Info {
+	info := Info{
+		Version:   ldflags.Version,
+		Commit:    ldflags.Commit,
+		Date:      ldflags.Date,
+		OS:        ldflags.OS,
+		Arch:      ldflags.Arch,
+		GoVersion: runtime.Version(),
+		Deps:      []Module{},
+	}
+	if info.OS == "" || info.OS == placeholderUnknown {
+		info.OS = runtime.GOOS
+	}
+	if info.Arch == "" || info.Arch == placeholderUnknown {
+		info.Arch = runtime.GOARCH
+	}
+	if buildInfo == nil {
+		return info
+	}
+
+	info.GoVersion = buildInfo.GoVersion
+	if (info.Version == "" || info.Version == placeholderVersion) && buildInfo.Main.Version != "" && buildInfo.Main.Version != "(devel)" {
+		info.Version = buildInfo.Main.Version
+	}
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" || info.Commit == placeholderCommit {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.Date == "" || info.Date == placeholderUnknown {
+				info.Date = setting.Value
+			}
+		case "vcs.modified":
+			info.Dirty = setting.Value == "true"
+		}
+	}
+	for _, dep := range buildInfo.Deps {
+		module := Module{Path: dep.Path, Version: dep.Version}
+		if dep.Replace != nil {
+			module.Replace = dep.Replace.Path
+			// Local replacements have no version
+			if dep.Replace.Version != "" {
+				module.Replace += " " + dep.Replace.Version
+			}
+		}
+		info.Deps = append(info.Deps, module)
+	}
+	return info
+}
@@ -0,0 +1,57 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInfo(t *testing.T) {
+	buildInfo := &debug.BuildInfo{
+		GoVersion: "go1.24.2",
+		Main:      debug.Module{Path: "github.com/jmaister/gots-template", Version: "v1.4.0"},
+		Deps: []*debug.Module{
+			{Path: "gorm.io/gorm", Version: "v1.31.1"},
+			{Path: "github.com/example/fork", Version: "v1.0.0", Replace: &debug.Module{Path: "../fork", Version: ""}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.time", Value: "2026-01-02T03:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	t.Run("Placeholders", func(t *testing.T) {
+		info := newInfo(Ldflags{Version: "dev", Commit: "none", Date: "unknown", OS: "unknown", Arch: "unknown"}, buildInfo)
+		assert.Equal(t, "v1.4.0", info.Version, "The module version of go install")
+		assert.Equal(t, "0123456789abcdef", info.Commit)
+		assert.Equal(t, "2026-01-02T03:04:05Z", info.Date)
+		assert.True(t, info.Dirty)
+		assert.Equal(t, runtime.GOOS, info.OS)
+		assert.Equal(t, runtime.GOARCH, info.Arch)
+		assert.Equal(t, "go1.24.2", info.GoVersion)
+		assert.Equal(t, []Module{
+			{Path: "gorm.io/gorm", Version: "v1.31.1"},
+			{Path: "github.com/example/fork", Version: "v1.0.0", Replace: "../fork"},
+		}, info.Deps)
+	})
+
+	t.Run("LdflagsWin", func(t *testing.T) {
+		info := newInfo(Ldflags{Version: "1.5.0", Commit: "fedcba", Date: "2026-02-03T00:00:00Z", OS: "linux", Arch: "arm64"}, buildInfo)
+		assert.Equal(t, "1.5.0", info.Version)
+		assert.Equal(t, "fedcba", info.Commit)
+		assert.Equal(t, "2026-02-03T00:00:00Z", info.Date)
+		assert.Equal(t, "linux", info.OS)
+		assert.Equal(t, "arm64", info.Arch)
+	})
+
+	t.Run("WithoutBuildInfo", func(t *testing.T) {
+		info := newInfo(Ldflags{Version: "dev", Commit: "none"}, nil)
+		assert.Equal(t, "dev", info.Version)
+		assert.Equal(t, "none", info.Commit)
+		assert.Equal(t, runtime.Version(), info.GoVersion)
+		assert.NotNil(t, info.Deps)
+	})
+}
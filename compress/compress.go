@@ -0,0 +1,295 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/jmaister/gots-template/config"
+)
+
+// Content codings of the Content-Encoding header
+const (
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+// encodings are the supported content codings, the preferred first when the client accepts several equally
+var encodings = []string{EncodingBrotli, EncodingGzip}
+
+// Compression levels of the responses compressed on the fly, trading some ratio for speed.
+// Precompressed files use the maximum levels at build time.
+const (
+	gzipLevel   = gzip.DefaultCompression
+	brotliLevel = 4
+)
+
+// encoder is the common interface of gzip.Writer and brotli.Writer
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	EncodingBrotli: {New: func() interface{} { return brotli.NewWriterLevel(nil, brotliLevel) }},
+	EncodingGzip: {New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzipLevel)
+		return w
+	}},
+}
+
+// NewMiddleware returns a net/http middleware compressing the responses with brotli or gzip, as
+// negotiated with the Accept-Encoding header of the request. Responses smaller than cfg.MinSize,
+// already encoded, partial or of a type that does not compress, like images, are sent as is.
+func NewMiddleware(cfg config.CompressionConfig) func(http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       negotiate(r.Header.Get("Accept-Encoding"), encodings),
+				minSize:        cfg.MinSize,
+			}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiate returns the coding of supported the client prefers according to its Accept-Encoding
+// header, or an empty string when it accepts none of them
+func negotiate(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(item, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if !found || strings.ToLower(strings.TrimSpace(name)) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				q = 0
+			}
+			quality = q
+		}
+		qualities[coding] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, coding := range supported {
+		quality, found := qualities[coding]
+		if !found {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = coding, quality
+		}
+	}
+	return best
+}
+
+// compressible reports whether a response of the given Content-Type is worth compressing
+func compressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	switch {
+	case mediaType == "text/event-stream":
+		// Events must reach the client as soon as they are written
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/javascript", "application/xml", "application/wasm",
+		"application/manifest+json", "image/svg+xml", "image/x-icon":
+		return true
+	}
+	return false
+}
+
+// addVary adds Accept-Encoding to the Vary header, once
+func addVary(h http.Header) {
+	for _, value := range h.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "*" || strings.EqualFold(field, "Accept-Encoding") {
+				return
+			}
+		}
+	}
+	h.Add("Vary", "Accept-Encoding")
+}
+
+// compressWriter buffers the start of the body until it knows whether the response is compressed:
+// as soon as it reaches minSize bytes, or when the handler returns or flushes
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	out     io.Writer
+	encoder encoder
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if status < http.StatusOK {
+		// Informational responses, like 103 Early Hints, go out right away
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.out != nil {
+		return w.out.Write(b)
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	if len(w.buf) == 0 && !w.eligible(b) {
+		err := w.start(false)
+		if err != nil {
+			return 0, err
+		}
+		return w.out.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) < w.minSize {
+		return len(b), nil
+	}
+	return len(b), w.start(true)
+}
+
+// eligible reports whether the response may be compressed, sniff being the start of its body
+func (w *compressWriter) eligible(sniff []byte) bool {
+	h := w.Header()
+	switch w.status {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		// Sniffed here, net/http would sniff the compressed bytes
+		contentType = http.DetectContentType(sniff)
+		h.Set("Content-Type", contentType)
+	}
+	if !compressible(contentType) {
+		return false
+	}
+
+	// The response depends on Accept-Encoding even when this one is not compressed
+	addVary(h)
+	if w.encoding == "" {
+		return false
+	}
+	length, err := strconv.Atoi(h.Get("Content-Length"))
+	return err != nil || length >= w.minSize
+}
+
+// start writes the header and the buffered body, compressed or not
+func (w *compressWriter) start(compress bool) error {
+	if compress {
+		h := w.Header()
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		// Ranges would refer to the uncompressed body
+		h.Del("Accept-Ranges")
+		etag := h.Get("ETag")
+		if etag != "" && !strings.HasPrefix(etag, "W/") {
+			// The compressed body is not byte for byte the tagged one
+			h.Set("ETag", "W/"+etag)
+		}
+
+		w.encoder = encoderPools[w.encoding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+		w.out = w.encoder
+	} else {
+		w.out = w.ResponseWriter
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.out.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// Flush sends what was written so far, compressed if the response is. Streamed responses are
+// compressed once they have a body, whatever its size.
+func (w *compressWriter) Flush() {
+	if w.out == nil {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		// A buffered body already passed eligible
+		w.start(len(w.buf) > 0)
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Close sends the responses smaller than minSize and terminates the compressed stream
+func (w *compressWriter) Close() error {
+	if w.out == nil {
+		if w.status == 0 {
+			// Nothing written, net/http sends the default response
+			return nil
+		}
+		err := w.start(false)
+		if err != nil {
+			return err
+		}
+	}
+	if w.encoder == nil {
+		return nil
+	}
+
+	err := w.encoder.Close()
+	w.encoder.Reset(nil)
+	encoderPools[w.encoding].Put(w.encoder)
+	w.encoder = nil
+	return err
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,239 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/andybalholm/brotli"
+	"github.com/jmaister/gots-template/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var largeJSON = `{"items":[` + strings.Repeat(`{"name":"item","enabled":true},`, 100) + `{}]}`
+
+// decode returns the body of a response, decompressed according to its Content-Encoding
+func decode(t *testing.T, w *httptest.ResponseRecorder) string {
+	var reader io.Reader = w.Body
+	switch w.Header().Get("Content-Encoding") {
+	case EncodingGzip:
+		gzipReader, err := gzip.NewReader(w.Body)
+		assert.NoError(t, err)
+		reader = gzipReader
+	case EncodingBrotli:
+		reader = brotli.NewReader(w.Body)
+	}
+	body, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func serve(handler http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", EncodingGzip},
+		{"gzip, deflate, br", EncodingBrotli},
+		{"GZIP;q=0.5, br;q=0.4", EncodingGzip},
+		{"br;q=0, gzip", EncodingGzip},
+		{"*", EncodingBrotli},
+		{"*;q=0.1, br;q=0", EncodingGzip},
+		{"gzip;q=0, br;q=invalid", ""},
+		{"deflate", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.encoding, negotiate(tt.acceptEncoding, encodings), tt.acceptEncoding)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	middleware := NewMiddleware(config.CompressionConfig{Enabled: true, MinSize: 1024})
+	jsonHandler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "2000")
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusCreated)
+		// Written in small chunks to cross the threshold in the middle of one
+		for i := 0; i < len(largeJSON); i += 100 {
+			w.Write([]byte(largeJSON[i:min(i+100, len(largeJSON))]))
+		}
+	}))
+
+	for _, encoding := range []string{EncodingGzip, EncodingBrotli} {
+		t.Run(encoding, func(t *testing.T) {
+			w := serve(jsonHandler, encoding)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, encoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			assert.Empty(t, w.Header().Get("Content-Length"))
+			assert.Equal(t, `W/"v1"`, w.Header().Get("ETag"))
+			assert.Less(t, w.Body.Len(), len(largeJSON))
+			assert.Equal(t, largeJSON, decode(t, w))
+		})
+	}
+
+	t.Run("NotAccepted", func(t *testing.T) {
+		w := serve(jsonHandler, "identity")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"), "Caches must not serve this response to clients accepting gzip")
+		assert.Equal(t, `"v1"`, w.Header().Get("ETag"))
+		assert.Equal(t, largeJSON, w.Body.String())
+	})
+
+	t.Run("BelowThreshold", func(t *testing.T) {
+		handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"ok"}`))
+		}))
+		w := serve(handler, "gzip")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Equal(t, `{"status":"ok"}`, w.Body.String())
+	})
+
+	t.Run("SniffedContentType", func(t *testing.T) {
+		html := "<!DOCTYPE html><html>" + strings.Repeat("<p>Hello</p>", 200) + "</html>"
+		handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(html))
+		}))
+		w := serve(handler, "gzip")
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, EncodingGzip, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, html, decode(t, w))
+	})
+
+	t.Run("SentAsIs", func(t *testing.T) {
+		body := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 1000)
+		responses := map[string]func(h http.Header){
+			"Incompressible": func(h http.Header) { h.Set("Content-Type", "image/png") },
+			"AlreadyEncoded": func(h http.Header) {
+				h.Set("Content-Type", "application/json")
+				h.Set("Content-Encoding", EncodingGzip)
+			},
+			"SmallContentLength": func(h http.Header) {
+				h.Set("Content-Type", "text/plain")
+				h.Set("Content-Length", "10")
+			},
+		}
+		for name, setHeaders := range responses {
+			handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				setHeaders(w.Header())
+				w.Write(body)
+			}))
+			w := serve(handler, "br, gzip")
+			assert.Equal(t, body, w.Body.Bytes(), name)
+			assert.NotEqual(t, EncodingBrotli, w.Header().Get("Content-Encoding"), name)
+		}
+	})
+
+	t.Run("NoBody", func(t *testing.T) {
+		handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		w := serve(handler, "gzip")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Zero(t, w.Body.Len())
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("first"))
+			http.NewResponseController(w).Flush()
+			w.Write([]byte(" second"))
+		}))
+		w := serve(handler, "gzip")
+		assert.True(t, w.Flushed)
+		assert.Equal(t, EncodingGzip, w.Header().Get("Content-Encoding"), "Streamed responses are compressed whatever their size")
+		assert.Equal(t, "first second", decode(t, w))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		handler := NewMiddleware(config.CompressionConfig{Enabled: false})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(largeJSON))
+		}))
+		w := serve(handler, "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Header().Get("Vary"))
+		assert.Equal(t, largeJSON, w.Body.String())
+	})
+}
+
+func TestServePrecompressed(t *testing.T) {
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write([]byte("console.log('app')"))
+	gzipWriter.Close()
+
+	webappFS := fstest.MapFS{
+		"assets/app.js":    {Data: []byte("console.log('app')")},
+		"assets/app.js.gz": {Data: gzipped.Bytes()},
+		"assets/app.js.br": {Data: []byte("brotli bytes")},
+		"assets/logo.png":  {Data: []byte("png")},
+	}
+	precompressed := func(name, acceptEncoding string) (*httptest.ResponseRecorder, bool) {
+		r := httptest.NewRequest(http.MethodGet, "/"+name, nil)
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		return w, ServePrecompressed(w, r, webappFS, name)
+	}
+
+	w, served := precompressed("assets/app.js", "gzip, br")
+	assert.True(t, served)
+	assert.Equal(t, EncodingBrotli, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "text/javascript; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Equal(t, "brotli bytes", w.Body.String())
+
+	w, served = precompressed("assets/app.js", "gzip")
+	assert.True(t, served)
+	assert.Equal(t, EncodingGzip, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "console.log('app')", decode(t, w))
+
+	r := httptest.NewRequest(http.MethodGet, "/assets/app.js", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	r.Header.Set("If-None-Match", `"abc-gzip"`)
+	w = httptest.NewRecorder()
+	w.Header().Set("ETag", `"abc"`)
+	assert.True(t, ServePrecompressed(w, r, webappFS, "assets/app.js"))
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, `"abc-gzip"`, w.Header().Get("ETag"))
+
+	w, served = precompressed("assets/app.js", "identity")
+	assert.False(t, served, "The caller serves the uncompressed file")
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Zero(t, w.Body.Len())
+
+	w, served = precompressed("assets/logo.png", "gzip, br")
+	assert.False(t, served, "There is no sibling")
+	assert.Empty(t, w.Header().Get("Vary"))
+
+	// The middleware leaves precompressed files alone
+	handler := NewMiddleware(config.CompressionConfig{Enabled: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServePrecompressed(w, r, webappFS, "assets/app.js")
+	}))
+	w = serve(handler, "gzip")
+	assert.Equal(t, EncodingGzip, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "console.log('app')", decode(t, w))
+}
@@ -0,0 +1,69 @@
+package compress
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// extensions are the file extensions of the precompressed siblings of each coding
+var extensions = map[string]string{
+	EncodingBrotli: ".br",
+	EncodingGzip:   ".gz",
+}
+
+// ServePrecompressed serves name from fsys using its .br or .gz sibling, compressed at build time,
+// when the client accepts that coding. It returns false, having written nothing, when there is no
+// acceptable sibling and the caller must serve name itself. A strong ETag set for name gets the
+// coding appended, so the compressed representation has its own.
+func ServePrecompressed(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) bool {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		return false
+	}
+
+	var available []string
+	for _, encoding := range encodings {
+		_, err := fs.Stat(fsys, name+extensions[encoding])
+		if err == nil {
+			available = append(available, encoding)
+		}
+	}
+	if len(available) == 0 {
+		return false
+	}
+
+	// The response depends on Accept-Encoding even when the sibling is not used
+	addVary(w.Header())
+	encoding := negotiate(r.Header.Get("Accept-Encoding"), available)
+	if encoding == "" {
+		return false
+	}
+
+	file, err := fsys.Open(name + extensions[encoding])
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		return false
+	}
+
+	etag := w.Header().Get("ETag")
+	if strings.HasPrefix(etag, `"`) {
+		// Each representation has its own strong ETag
+		w.Header().Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+encoding+`"`)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Encoding", encoding)
+	http.ServeContent(w, r, name, stat.ModTime(), content)
+	return true
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	cfg := Default()
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, "127.0.0.1:8081", cfg.Server.Addr())
+	assert.Equal(t, "http://localhost:8080", cfg.Gateway.BaseURL())
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("AggregatesAllProblems", func(t *testing.T) {
+		cfg := Default()
+		cfg.Server.Host = ""
+		cfg.Server.Port = 70000
+		cfg.Server.ShutdownTimeout = 0
+		cfg.Database.URL = "mongodb://localhost"
+		cfg.Gateway.URL = "not a url"
+		cfg.Session.MaxAge = -time.Second
+
+		err := cfg.Validate()
+		assert.Error(t, err)
+
+		var validationErr *ValidationError
+		assert.True(t, errors.As(err, &validationErr))
+		assert.Len(t, validationErr.Problems, 6)
+		assert.Contains(t, err.Error(), "server.host")
+		assert.Contains(t, err.Error(), "server.port")
+		assert.Contains(t, err.Error(), "server.shutdown_timeout")
+		assert.Contains(t, err.Error(), "database.url")
+		assert.Contains(t, err.Error(), "gateway.url")
+		assert.Contains(t, err.Error(), "session.max_age")
+	})
+
+	t.Run("HeaderVerification", func(t *testing.T) {
+		cfg := Default()
+		cfg.Session.HeaderVerification = HeaderVerificationHMAC
+		cfg.Session.TrustedProxies = []string{"10.0.0.0/8", "::1", "10.0.0.0/99", "proxy"}
+		cfg.Session.HeaderFailure = "ignore"
+
+		err := cfg.Validate()
+		var validationErr *ValidationError
+		assert.True(t, errors.As(err, &validationErr))
+		assert.Len(t, validationErr.Problems, 4)
+		assert.Contains(t, err.Error(), "session.header_secret")
+		assert.Contains(t, err.Error(), `invalid CIDR "10.0.0.0/99"`)
+		assert.Contains(t, err.Error(), `invalid IP address "proxy"`)
+		assert.Contains(t, err.Error(), "session.header_failure")
+
+		cfg.Session.HeaderVerification = HeaderVerificationJWT
+		cfg.Session.HeaderPublicKeyFile = "gateway.pem"
+		cfg.Session.TrustedProxies = []string{"10.0.0.0/8"}
+		cfg.Session.HeaderFailure = HeaderFailureStrip
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("CORSOrigins", func(t *testing.T) {
+		cfg := Default()
+		cfg.CORS.AllowedOrigins = []string{"https://app.example.com", "http://localhost:5173", "https://*.example.org", "*"}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.CORS.AllowCredentials = true
+		cfg.CORS.AllowedOrigins = []string{"*", "app.example.com", "https://app.*.com", "https://example.com/path"}
+		err := cfg.Validate()
+		var validationErr *ValidationError
+		assert.True(t, errors.As(err, &validationErr))
+		assert.Len(t, validationErr.Problems, 4)
+		assert.Contains(t, err.Error(), "cannot be used with cors.allow_credentials")
+	})
+
+	t.Run("Log", func(t *testing.T) {
+		cfg := Default()
+		cfg.Log.Format = "xml"
+		cfg.Log.Level = "verbose"
+		cfg.Database.SlowQueryThreshold = -time.Second
+		cfg.AccessLog.SampleEvery = 0
+		cfg.AccessLog.SamplePaths = []string{"api/health"}
+		cfg.AccessLog.RedactPaths = []string{"/reset/token"}
+
+		err := cfg.Validate()
+		var validationErr *ValidationError
+		assert.True(t, errors.As(err, &validationErr))
+		assert.Len(t, validationErr.Problems, 6)
+		assert.Contains(t, err.Error(), "log.format")
+		assert.Contains(t, err.Error(), "log.level")
+		assert.Contains(t, err.Error(), "database.slow_query_threshold")
+		assert.Contains(t, err.Error(), "access_log.sample_every")
+		assert.Contains(t, err.Error(), "access_log.sample_paths")
+		assert.Contains(t, err.Error(), "access_log.redact_paths")
+	})
+
+	t.Run("MetricsAddr", func(t *testing.T) {
+		cfg := Default()
+		cfg.Metrics.Addr = "127.0.0.1:9090"
+		assert.NoError(t, cfg.Validate())
+
+		for _, addr := range []string{"9090", "127.0.0.1:", cfg.Server.Addr()} {
+			cfg.Metrics.Addr = addr
+			err := cfg.Validate()
+			assert.ErrorContains(t, err, "metrics.addr", addr)
+		}
+	})
+
+	t.Run("Tracing", func(t *testing.T) {
+		cfg := Default()
+		cfg.Tracing.Exporter = TracingExporterOTLP
+		cfg.Tracing.Endpoint = "http://localhost:4318/v1/traces"
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Tracing.Endpoint = "localhost:4318"
+		cfg.Tracing.ServiceName = ""
+		err := cfg.Validate()
+		var validationErr *ValidationError
+		assert.True(t, errors.As(err, &validationErr))
+		assert.Len(t, validationErr.Problems, 2)
+		assert.Contains(t, err.Error(), "tracing.endpoint")
+		assert.Contains(t, err.Error(), "tracing.service_name")
+
+		cfg = Default()
+		cfg.Tracing.Exporter = "jaeger"
+		assert.ErrorContains(t, cfg.Validate(), "tracing.exporter")
+	})
+
+	t.Run("Health", func(t *testing.T) {
+		cfg := Default()
+		cfg.Health.CheckTimeout = 0
+		cfg.Health.CacheTTL = -time.Second
+		cfg.Health.MinFreeDiskMB = -1
+
+		err := cfg.Validate()
+		var validationErr *ValidationError
+		assert.True(t, errors.As(err, &validationErr))
+		assert.Len(t, validationErr.Problems, 3)
+		assert.Contains(t, err.Error(), "health.check_timeout")
+		assert.Contains(t, err.Error(), "health.cache_ttl")
+		assert.Contains(t, err.Error(), "health.min_free_disk_mb")
+	})
+
+	t.Run("Compression", func(t *testing.T) {
+		cfg := Default()
+		cfg.Compression.MinSize = 0
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Compression.MinSize = -1
+		assert.ErrorContains(t, cfg.Validate(), "compression.min_size")
+	})
+
+	t.Run("GatewayURLOverridesHostAndPort", func(t *testing.T) {
+		cfg := Default()
+		cfg.Gateway.URL = "https://gateway.example.com/"
+		assert.NoError(t, cfg.Validate())
+		assert.Equal(t, "https://gateway.example.com", cfg.Gateway.BaseURL())
+	})
+}
+
+func TestDatabaseTarget(t *testing.T) {
+	tests := []struct {
+		url     string
+		dialect DatabaseDialect
+		dsn     string
+	}{
+		{"sqlite://data/app.db", DialectSQLite, "data/app.db"},
+		{"app.db", DialectSQLite, "app.db"},
+		{"sqlite://:memory:", DialectSQLite, SQLiteMemory},
+		{"postgres://app:secret@db:5432/app?sslmode=disable", DialectPostgres, "postgres://app:secret@db:5432/app?sslmode=disable"},
+		{"postgresql://db/app", DialectPostgres, "postgresql://db/app"},
+		{"mysql://app:secret@db:3307/app", DialectMySQL, "app:secret@tcp(db:3307)/app?parseTime=true"},
+		{"mysql://app@db/app?charset=utf8mb4", DialectMySQL, "app@tcp(db:3306)/app?charset=utf8mb4&parseTime=true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			target, err := DatabaseConfig{URL: tt.url}.Target()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.dialect, target.Dialect)
+			assert.Equal(t, tt.dsn, target.DSN)
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, invalid := range []string{"", "sqlite://", "mongodb://db/app", "mysql://db", "postgres:///app"} {
+			_, err := DatabaseConfig{URL: invalid}.Target()
+			assert.Error(t, err, invalid)
+		}
+	})
+
+	t.Run("SQLiteFile", func(t *testing.T) {
+		files := map[string]string{
+			"sqlite://data/app.db":                 "data/app.db",
+			"sqlite://file:app.db?_pragma=busy(1)": "app.db",
+			"sqlite://:memory:":                    "",
+			"sqlite://file:test?mode=memory":       "",
+			"postgres://app:secret@db:5432/app":    "",
+		}
+		for databaseURL, file := range files {
+			target, err := DatabaseConfig{URL: databaseURL}.Target()
+			assert.NoError(t, err)
+			assert.Equal(t, file, target.SQLiteFile(), databaseURL)
+		}
+	})
+
+	t.Run("ErrorsDoNotLeakPasswords", func(t *testing.T) {
+		_, err := DatabaseConfig{URL: "mysql://app:secret@db"}.Target()
+		assert.Error(t, err)
+		assert.NotContains(t, err.Error(), "secret")
+	})
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+// entryByKey finds an entry by its key
+func entryByKey(t *testing.T, entries []Entry, key string) Entry {
+	for _, entry := range entries {
+		if entry.Key == key {
+			return entry
+		}
+	}
+	t.Fatalf("entry %s not found", key)
+	return Entry{}
+}
+
+func TestEffective(t *testing.T) {
+	configFile := writeFile(t, "config.yaml", "server:\n  host: 0.0.0.0\n")
+	envFile := writeFile(t, ".env", "ADMIN_TOKEN=super-secret\n")
+
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	BindFlags(flags)
+	err := flags.Parse([]string{"--port", "9000"})
+	assert.NoError(t, err)
+
+	entries, err := Effective(LoadOptions{
+		ConfigFile: configFile,
+		EnvFile:    envFile,
+		Flags:      flags,
+		LookupEnv: envFrom(map[string]string{
+			"API_URL":      "http://gateway.internal:8080",
+			"DATABASE_URL": "sqlite://app.db",
+		}),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, entries, len(settings))
+
+	host := entryByKey(t, entries, "server.host")
+	assert.Equal(t, "0.0.0.0", host.Value)
+	assert.Equal(t, "file "+configFile, host.Source)
+
+	port := entryByKey(t, entries, "server.port")
+	assert.Equal(t, "9000", port.Value)
+	assert.Equal(t, "flag --port", port.Source)
+
+	apiURL := entryByKey(t, entries, "gateway.url")
+	assert.Equal(t, "http://gateway.internal:8080", apiURL.Value)
+	assert.Equal(t, "environment variable API_URL", apiURL.Source)
+
+	token := entryByKey(t, entries, "gateway.admin_token")
+	assert.Equal(t, Redacted, token.Value)
+	assert.True(t, token.Secret)
+	assert.Equal(t, "file "+envFile, token.Source)
+
+	timeout := entryByKey(t, entries, "server.shutdown_timeout")
+	assert.Equal(t, "30s", timeout.Value)
+	assert.Equal(t, SourceDefault, timeout.Source)
+}
+
+func TestEffectiveInvalidConfig(t *testing.T) {
+	entries, err := Effective(LoadOptions{
+		EnvFile:   filepath.Join(t.TempDir(), "missing.env"),
+		LookupEnv: envFrom(map[string]string{"SERVER_PORT": "99999"}),
+	})
+	assert.Error(t, err)
+	assert.Len(t, entries, len(settings), "Entries should be returned even when invalid")
+}
+
+func TestRedaction(t *testing.T) {
+	assert.True(t, isSecretKey("notification.smtp.password"))
+	assert.True(t, isSecretKey("gateway.admin_token"))
+	assert.False(t, isSecretKey("server.port"))
+
+	assert.Equal(t, "postgres://app:********@db:5432/app", redactURLPassword("postgres://app:hunter2@db:5432/app"))
+	assert.Equal(t, "postgres://app@db:5432/app", redactURLPassword("postgres://app@db:5432/app"))
+	assert.Equal(t, "sqlite://gots-template.db", redactURLPassword("sqlite://gots-template.db"))
+}
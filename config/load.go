@@ -0,0 +1,203 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvConfigFile is the environment variable pointing to the YAML config file
+const EnvConfigFile = "CONFIG_FILE"
+
+// DefaultEnvFile is the dotenv file read when LoadOptions.EnvFile is empty
+const DefaultEnvFile = ".env"
+
+// SourceDefault is the source reported for values that were not overridden
+const SourceDefault = "default"
+
+// LoadOptions controls where Load reads the configuration from
+type LoadOptions struct {
+	// ConfigFile is the YAML file to read. When empty, CONFIG_FILE from the
+	// environment or the .env file is used, and no file is read if that is empty too.
+	ConfigFile string
+	// EnvFile is the dotenv file to read, DefaultEnvFile when empty. A missing file is ignored.
+	EnvFile string
+	// Flags are the command line flags. Only flags explicitly set on the command line are applied.
+	Flags *pflag.FlagSet
+	// LookupEnv reads environment variables, os.LookupEnv when nil
+	LookupEnv func(key string) (string, bool)
+}
+
+// BindFlags registers the command line flags of every setting that has one
+func BindFlags(flags *pflag.FlagSet) {
+	defaults := Default()
+	for _, s := range settings {
+		if s.Flag == "" {
+			continue
+		}
+		flags.String(s.Flag, s.get(defaults), s.Description)
+	}
+}
+
+// Load builds the configuration applying, from lowest to highest precedence:
+// defaults, the YAML config file, the .env file, environment variables and flags.
+// The result is validated; all problems are reported together in a *ValidationError.
+func Load(opts LoadOptions) (*AppConfig, error) {
+	cfg, _, err := load(opts)
+	return cfg, err
+}
+
+// load implements Load and also returns, for every setting key, the source its value came from
+func load(opts LoadOptions) (*AppConfig, map[string]string, error) {
+	lookupEnv := opts.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	envFile := opts.EnvFile
+	if envFile == "" {
+		envFile = DefaultEnvFile
+	}
+
+	cfg := Default()
+	problems := &ValidationError{}
+	sources := make(map[string]string, len(settings))
+	for _, s := range settings {
+		sources[s.Key] = SourceDefault
+	}
+
+	dotenv, err := godotenv.Read(envFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		problems.Problems = append(problems.Problems, fmt.Sprintf("%s: %v", envFile, err))
+	}
+
+	configFile := opts.ConfigFile
+	if configFile == "" {
+		configFile = dotenv[EnvConfigFile]
+		value, ok := lookupEnv(EnvConfigFile)
+		if ok {
+			configFile = value
+		}
+	}
+
+	// 1. YAML config file
+	if configFile != "" {
+		values, err := readYAMLFile(configFile)
+		if err != nil {
+			problems.Problems = append(problems.Problems, fmt.Sprintf("%s: %v", configFile, err))
+		}
+		for _, key := range sortedKeys(values) {
+			s, ok := findSetting(key)
+			if !ok {
+				problems.add(key, fmt.Sprintf("unknown key in %s", configFile))
+				continue
+			}
+			apply(cfg, s, values[key], "file "+configFile, sources, problems)
+		}
+	}
+
+	// 2. .env file
+	for _, s := range settings {
+		value, ok := dotenv[s.Env]
+		if ok {
+			apply(cfg, s, value, "file "+envFile, sources, problems)
+		}
+	}
+
+	// 3. Environment variables
+	for _, s := range settings {
+		value, ok := lookupEnv(s.Env)
+		if ok {
+			apply(cfg, s, value, "environment variable "+s.Env, sources, problems)
+		}
+	}
+
+	// 4. Command line flags
+	if opts.Flags != nil {
+		for _, s := range settings {
+			if s.Flag == "" || !opts.Flags.Changed(s.Flag) {
+				continue
+			}
+			apply(cfg, s, opts.Flags.Lookup(s.Flag).Value.String(), "flag --"+s.Flag, sources, problems)
+		}
+	}
+
+	err = cfg.Validate()
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		problems.merge(validationErr)
+	}
+
+	if len(problems.Problems) > 0 {
+		return cfg, sources, problems
+	}
+	return cfg, sources, nil
+}
+
+// apply sets a single value and records where it came from,
+// or records a problem that names the source if the value cannot be parsed
+func apply(cfg *AppConfig, s setting, value string, source string, sources map[string]string, problems *ValidationError) {
+	err := s.set(cfg, value)
+	if err != nil {
+		problems.add(s.Key, fmt.Sprintf("%v (from %s)", err, source))
+		return
+	}
+	sources[s.Key] = source
+}
+
+// readYAMLFile reads a YAML file into a flat map of dotted keys to string values
+func readYAMLFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]interface{}
+	err = yaml.Unmarshal(content, &tree)
+	if err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	values := make(map[string]string)
+	flatten("", tree, values)
+	return values, nil
+}
+
+// flatten converts nested YAML maps into dotted keys, e.g. server: {port: 8081} becomes server.port
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(key, v, values)
+		case []interface{}:
+			// Lists are read like comma separated values
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			// An empty value keeps the default
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
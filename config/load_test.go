@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFile writes content to a file in the test temporary directory
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o600)
+	assert.NoError(t, err)
+	return path
+}
+
+// envFrom returns a LookupEnv function backed by a map
+func envFrom(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(LoadOptions{
+		EnvFile:   filepath.Join(t.TempDir(), "missing.env"),
+		LookupEnv: envFrom(nil),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	configFile := writeFile(t, "config.yaml", `
+server:
+  host: 0.0.0.0
+  port: 9000
+  shutdown_timeout: 10s
+database:
+  url: sqlite://from-file.db
+gateway:
+  admin_token: file-token
+`)
+	envFile := writeFile(t, ".env", "SERVER_PORT=9001\nDATABASE_URL=sqlite://from-dotenv.db\nSESSION_MAX_AGE=3600\n")
+
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	BindFlags(flags)
+	err := flags.Parse([]string{"--port", "9003"})
+	assert.NoError(t, err)
+
+	cfg, err := Load(LoadOptions{
+		ConfigFile: configFile,
+		EnvFile:    envFile,
+		Flags:      flags,
+		LookupEnv: envFrom(map[string]string{
+			"SERVER_PORT":  "9002",
+			"DATABASE_URL": "sqlite://from-env.db",
+			"API_URL":      "http://gateway:8080",
+		}),
+	})
+	assert.NoError(t, err)
+
+	// Only in the file
+	assert.Equal(t, "0.0.0.0", cfg.Server.Host)
+	assert.Equal(t, 10*time.Second, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, "file-token", cfg.Gateway.AdminToken)
+	// .env overrides the file, environment overrides .env
+	assert.Equal(t, time.Hour, cfg.Session.MaxAge)
+	assert.Equal(t, "sqlite://from-env.db", cfg.Database.URL)
+	assert.Equal(t, "http://gateway:8080", cfg.Gateway.BaseURL())
+	// Flags override everything
+	assert.Equal(t, 9003, cfg.Server.Port)
+}
+
+func TestLoadConfigFileFromEnvironment(t *testing.T) {
+	configFile := writeFile(t, "config.yaml", "server:\n  port: 9100\n")
+
+	cfg, err := Load(LoadOptions{
+		EnvFile:   filepath.Join(t.TempDir(), "missing.env"),
+		LookupEnv: envFrom(map[string]string{EnvConfigFile: configFile}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 9100, cfg.Server.Port)
+}
+
+func TestLoadLists(t *testing.T) {
+	configFile := writeFile(t, "config.yaml", `
+session:
+  trusted_proxies:
+    - 10.0.0.0/8
+    - 127.0.0.1
+`)
+
+	cfg, err := Load(LoadOptions{
+		ConfigFile: configFile,
+		EnvFile:    filepath.Join(t.TempDir(), "missing.env"),
+		LookupEnv:  envFrom(nil),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "127.0.0.1"}, cfg.Session.TrustedProxies)
+
+	// Environment variables are comma separated
+	cfg, err = Load(LoadOptions{
+		ConfigFile: configFile,
+		EnvFile:    filepath.Join(t.TempDir(), "missing.env"),
+		LookupEnv:  envFrom(map[string]string{"TRUSTED_PROXIES": "192.168.0.0/16, ::1,"}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.0.0/16", "::1"}, cfg.Session.TrustedProxies)
+}
+
+func TestLoadAggregatesErrors(t *testing.T) {
+	configFile := writeFile(t, "config.yaml", `
+server:
+  port: not-a-number
+  unknown: value
+`)
+
+	_, err := Load(LoadOptions{
+		ConfigFile: configFile,
+		EnvFile:    filepath.Join(t.TempDir(), "missing.env"),
+		LookupEnv: envFrom(map[string]string{
+			"SHUTDOWN_TIMEOUT": "soon",
+			"TG_SERVER_PORT":   "0",
+		}),
+	})
+	assert.Error(t, err)
+
+	validationErr, ok := err.(*ValidationError)
+	assert.True(t, ok, "Error should be a *ValidationError")
+	assert.Len(t, validationErr.Problems, 4)
+	assert.Contains(t, err.Error(), "server.port: must be an integer")
+	assert.Contains(t, err.Error(), "server.unknown: unknown key")
+	assert.Contains(t, err.Error(), "environment variable SHUTDOWN_TIMEOUT")
+	assert.Contains(t, err.Error(), "gateway.port: must be between 1 and 65535")
+}
+
+func TestLoadMissingConfigFile(t *testing.T) {
+	_, err := Load(LoadOptions{
+		ConfigFile: filepath.Join(t.TempDir(), "missing.yaml"),
+		EnvFile:    filepath.Join(t.TempDir(), "missing.env"),
+		LookupEnv:  envFrom(nil),
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing.yaml")
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting describes a single configuration value and every source it can be read from.
+// The settings table is the single place where keys, environment variables and flags are declared.
+type setting struct {
+	// Key is the dotted path of the value in the YAML config file
+	Key string
+	// Env is the environment variable (also read from .env)
+	Env string
+	// Flag is the command line flag on the run command, empty if there is none
+	Flag string
+	// Description is shown in the flag usage
+	Description string
+	// Secret values are redacted when the configuration is printed
+	Secret bool
+
+	get func(c *AppConfig) string
+	set func(c *AppConfig, value string) error
+}
+
+var settings = []setting{
+	stringSetting("server.host", "SERVER_HOST", "host", "Host the HTTP server listens on",
+		func(c *AppConfig) *string { return &c.Server.Host }),
+	intSetting("server.port", "SERVER_PORT", "port", "Port the HTTP server listens on",
+		func(c *AppConfig) *int { return &c.Server.Port }),
+	durationSetting("server.shutdown_timeout", "SHUTDOWN_TIMEOUT", "shutdown-timeout", "Time given to in-flight requests on shutdown",
+		func(c *AppConfig) *time.Duration { return &c.Server.ShutdownTimeout }),
+
+	stringSetting("database.url", "DATABASE_URL", "database-url", "Database URL: sqlite://file.db, sqlite://:memory:, postgres://... or mysql://...",
+		func(c *AppConfig) *string { return &c.Database.URL }),
+	intSetting("database.max_open_conns", "DB_MAX_OPEN_CONNS", "", "Maximum open connections, 0 uses the dialect default",
+		func(c *AppConfig) *int { return &c.Database.MaxOpenConns }),
+	intSetting("database.max_idle_conns", "DB_MAX_IDLE_CONNS", "", "Maximum idle connections, 0 uses the dialect default",
+		func(c *AppConfig) *int { return &c.Database.MaxIdleConns }),
+	durationSetting("database.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", "", "Maximum connection lifetime, 0 uses the dialect default",
+		func(c *AppConfig) *time.Duration { return &c.Database.ConnMaxLifetime }),
+	durationSetting("database.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME", "", "Maximum connection idle time, 0 uses the dialect default",
+		func(c *AppConfig) *time.Duration { return &c.Database.ConnMaxIdleTime }),
+	durationSetting("database.slow_query_threshold", "DB_SLOW_QUERY_THRESHOLD", "", "Queries taking longer are logged as warnings, 0 disables it",
+		func(c *AppConfig) *time.Duration { return &c.Database.SlowQueryThreshold }),
+
+	stringSetting("gateway.url", "API_URL", "", "Taronja Gateway base URL, overrides gateway host and port",
+		func(c *AppConfig) *string { return &c.Gateway.URL }),
+	stringSetting("gateway.host", "TG_SERVER_HOST", "", "Taronja Gateway host",
+		func(c *AppConfig) *string { return &c.Gateway.Host }),
+	intSetting("gateway.port", "TG_SERVER_PORT", "", "Taronja Gateway port",
+		func(c *AppConfig) *int { return &c.Gateway.Port }),
+	secret(stringSetting("gateway.admin_token", "ADMIN_TOKEN", "", "Token for admin operations against Taronja Gateway",
+		func(c *AppConfig) *string { return &c.Gateway.AdminToken })),
+
+	durationSetting("session.max_age", "SESSION_MAX_AGE", "", "Maximum session age, in seconds or as a duration",
+		func(c *AppConfig) *time.Duration { return &c.Session.MaxAge }),
+	stringSetting("session.header_verification", "HEADER_VERIFICATION", "", "Verification of the gateway identity headers: off, hmac or jwt",
+		func(c *AppConfig) *string { return &c.Session.HeaderVerification }),
+	secret(stringSetting("session.header_secret", "HEADER_SECRET", "", "Shared secret of hmac header verification and HS256 tokens",
+		func(c *AppConfig) *string { return &c.Session.HeaderSecret })),
+	stringSetting("session.header_public_key_file", "HEADER_PUBLIC_KEY_FILE", "", "PEM public key of RS256 and ES256 header tokens",
+		func(c *AppConfig) *string { return &c.Session.HeaderPublicKeyFile }),
+	stringListSetting("session.trusted_proxies", "TRUSTED_PROXIES", "", "Comma separated IPs or CIDRs allowed to send identity headers",
+		func(c *AppConfig) *[]string { return &c.Session.TrustedProxies }),
+	stringSetting("session.header_failure", "HEADER_FAILURE", "", "Requests failing header verification: reject or strip",
+		func(c *AppConfig) *string { return &c.Session.HeaderFailure }),
+
+	stringListSetting("cors.allowed_origins", "CORS_ALLOWED_ORIGINS", "", "Comma separated origins allowed by CORS, e.g. https://*.example.com, none disables CORS",
+		func(c *AppConfig) *[]string { return &c.CORS.AllowedOrigins }),
+	stringListSetting("cors.allowed_headers", "CORS_ALLOWED_HEADERS", "", "Comma separated request headers allowed by CORS",
+		func(c *AppConfig) *[]string { return &c.CORS.AllowedHeaders }),
+	stringListSetting("cors.exposed_headers", "CORS_EXPOSED_HEADERS", "", "Comma separated response headers exposed by CORS",
+		func(c *AppConfig) *[]string { return &c.CORS.ExposedHeaders }),
+	boolSetting("cors.allow_credentials", "CORS_ALLOW_CREDENTIALS", "", "Allow cookies on cross-origin requests",
+		func(c *AppConfig) *bool { return &c.CORS.AllowCredentials }),
+	durationSetting("cors.max_age", "CORS_MAX_AGE", "", "How long browsers cache preflight responses",
+		func(c *AppConfig) *time.Duration { return &c.CORS.MaxAge }),
+
+	stringSetting("log.format", "LOG_FORMAT", "log-format", "Log format: text or json",
+		func(c *AppConfig) *string { return &c.Log.Format }),
+	stringSetting("log.level", "LOG_LEVEL", "log-level", "Minimum log level: debug, info, warn or error",
+		func(c *AppConfig) *string { return &c.Log.Level }),
+	stringSetting("log.file", "LOG_FILE", "log-file", "File logs are appended to, standard error when empty",
+		func(c *AppConfig) *string { return &c.Log.File }),
+
+	boolSetting("access_log.enabled", "ACCESS_LOG_ENABLED", "", "Log every HTTP request",
+		func(c *AppConfig) *bool { return &c.AccessLog.Enabled }),
+	stringListSetting("access_log.headers", "ACCESS_LOG_HEADERS", "", "Comma separated request headers added to the access log",
+		func(c *AppConfig) *[]string { return &c.AccessLog.Headers }),
+	stringListSetting("access_log.redact_headers", "ACCESS_LOG_REDACT_HEADERS", "", "Comma separated headers whose values are never logged",
+		func(c *AppConfig) *[]string { return &c.AccessLog.RedactHeaders }),
+	stringListSetting("access_log.redact_query_params", "ACCESS_LOG_REDACT_QUERY_PARAMS", "", "Comma separated query parameters redacted from the logged path",
+		func(c *AppConfig) *[]string { return &c.AccessLog.RedactQueryParams }),
+	stringListSetting("access_log.redact_paths", "ACCESS_LOG_REDACT_PATHS", "", "Comma separated path templates whose {name} segments are redacted from the logged path",
+		func(c *AppConfig) *[]string { return &c.AccessLog.RedactPaths }),
+	stringListSetting("access_log.sample_paths", "ACCESS_LOG_SAMPLE_PATHS", "", "Comma separated path prefixes whose successful requests are sampled",
+		func(c *AppConfig) *[]string { return &c.AccessLog.SamplePaths }),
+	intSetting("access_log.sample_every", "ACCESS_LOG_SAMPLE_EVERY", "", "Log one in this many successful requests of the sampled paths",
+		func(c *AppConfig) *int { return &c.AccessLog.SampleEvery }),
+
+	boolSetting("metrics.enabled", "METRICS_ENABLED", "", "Expose Prometheus metrics on /metrics",
+		func(c *AppConfig) *bool { return &c.Metrics.Enabled }),
+	stringSetting("metrics.addr", "METRICS_ADDR", "metrics-addr", "Separate host:port serving /metrics, the main port when empty",
+		func(c *AppConfig) *string { return &c.Metrics.Addr }),
+
+	stringSetting("tracing.exporter", "TRACING_EXPORTER", "tracing-exporter", "Trace exporter: none, otlp or stdout",
+		func(c *AppConfig) *string { return &c.Tracing.Exporter }),
+	stringSetting("tracing.endpoint", "TRACING_ENDPOINT", "", "OTLP/HTTP URL of the otlp trace exporter",
+		func(c *AppConfig) *string { return &c.Tracing.Endpoint }),
+	stringSetting("tracing.file", "TRACING_FILE", "", "File the stdout trace exporter appends to, standard output when empty",
+		func(c *AppConfig) *string { return &c.Tracing.File }),
+	stringSetting("tracing.service_name", "OTEL_SERVICE_NAME", "", "Service name of the exported spans",
+		func(c *AppConfig) *string { return &c.Tracing.ServiceName }),
+
+	durationSetting("health.check_timeout", "HEALTH_CHECK_TIMEOUT", "", "Timeout of each readiness check",
+		func(c *AppConfig) *time.Duration { return &c.Health.CheckTimeout }),
+	durationSetting("health.cache_ttl", "HEALTH_CACHE_TTL", "", "How long readiness check results are reused, 0 disables caching",
+		func(c *AppConfig) *time.Duration { return &c.Health.CacheTTL }),
+	intSetting("health.min_free_disk_mb", "HEALTH_MIN_FREE_DISK_MB", "", "Free disk space in MB required next to the SQLite database, 0 disables the check",
+		func(c *AppConfig) *int { return &c.Health.MinFreeDiskMB }),
+
+	boolSetting("compression.enabled", "COMPRESSION_ENABLED", "", "Compress responses with gzip or brotli when the client accepts it",
+		func(c *AppConfig) *bool { return &c.Compression.Enabled }),
+	intSetting("compression.min_size", "COMPRESSION_MIN_SIZE", "", "Body size in bytes below which responses are not compressed",
+		func(c *AppConfig) *int { return &c.Compression.MinSize }),
+}
+
+// findSetting returns the setting with the given YAML key
+func findSetting(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// secret marks a setting as secret so its value is never printed
+func secret(s setting) setting {
+	s.Secret = true
+	return s
+}
+
+func stringSetting(key, env, flag, description string, field func(c *AppConfig) *string) setting {
+	return setting{
+		Key:         key,
+		Env:         env,
+		Flag:        flag,
+		Description: description,
+		get: func(c *AppConfig) string {
+			return *field(c)
+		},
+		set: func(c *AppConfig, value string) error {
+			*field(c) = value
+			return nil
+		},
+	}
+}
+
+// stringListSetting accepts comma separated values, or a list in the YAML config file
+func stringListSetting(key, env, flag, description string, field func(c *AppConfig) *[]string) setting {
+	return setting{
+		Key:         key,
+		Env:         env,
+		Flag:        flag,
+		Description: description,
+		get: func(c *AppConfig) string {
+			return strings.Join(*field(c), ",")
+		},
+		set: func(c *AppConfig, value string) error {
+			var values []string
+			for _, item := range strings.Split(value, ",") {
+				item = strings.TrimSpace(item)
+				if item != "" {
+					values = append(values, item)
+				}
+			}
+			*field(c) = values
+			return nil
+		},
+	}
+}
+
+func boolSetting(key, env, flag, description string, field func(c *AppConfig) *bool) setting {
+	return setting{
+		Key:         key,
+		Env:         env,
+		Flag:        flag,
+		Description: description,
+		get: func(c *AppConfig) string {
+			return strconv.FormatBool(*field(c))
+		},
+		set: func(c *AppConfig, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("must be true or false, got %q", value)
+			}
+			*field(c) = b
+			return nil
+		},
+	}
+}
+
+func intSetting(key, env, flag, description string, field func(c *AppConfig) *int) setting {
+	return setting{
+		Key:         key,
+		Env:         env,
+		Flag:        flag,
+		Description: description,
+		get: func(c *AppConfig) string {
+			return strconv.Itoa(*field(c))
+		},
+		set: func(c *AppConfig, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("must be an integer, got %q", value)
+			}
+			*field(c) = n
+			return nil
+		},
+	}
+}
+
+// durationSetting accepts Go durations ("30s", "24h") or a plain number of seconds
+func durationSetting(key, env, flag, description string, field func(c *AppConfig) *time.Duration) setting {
+	return setting{
+		Key:         key,
+		Env:         env,
+		Flag:        flag,
+		Description: description,
+		get: func(c *AppConfig) string {
+			return field(c).String()
+		},
+		set: func(c *AppConfig, value string) error {
+			seconds, err := strconv.Atoi(value)
+			if err == nil {
+				*field(c) = time.Duration(seconds) * time.Second
+				return nil
+			}
+
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("must be a duration (e.g. 30s) or a number of seconds, got %q", value)
+			}
+			*field(c) = d
+			return nil
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and validate the application configuration",
+	Long:  `Print the effective configuration or validate it without starting the server.`,
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Print the effective configuration",
+	Long: `Print every configuration value after applying defaults, the YAML config file, .env,
+environment variables and flags, together with the source each value came from.
+Secrets such as ADMIN_TOKEN are redacted.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFile, _ := cmd.Flags().GetString("config")
+		format, _ := cmd.Flags().GetString("format")
+
+		entries, loadErr := config.Effective(config.LoadOptions{ConfigFile: configFile})
+
+		switch format {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			err := encoder.Encode(entries)
+			if err != nil {
+				return err
+			}
+		case "text":
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tENV\tVALUE\tSOURCE")
+			for _, entry := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Key, entry.Env, entry.Value, entry.Source)
+			}
+			w.Flush()
+		default:
+			return fmt.Errorf("unknown format %q, use text or json", format)
+		}
+
+		// Still print the values, then fail so scripts notice the invalid configuration
+		return loadErr
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [config-file]",
+	Short: "Validate the configuration without starting the server",
+	Long: `Load and validate the configuration exactly like "run" does, without opening the database
+or starting the server. Exits with a non-zero status and lists every problem when it is invalid.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFile, _ := cmd.Flags().GetString("config")
+		if len(args) == 1 {
+			configFile = args[0]
+		}
+
+		_, err := config.Load(config.LoadOptions{ConfigFile: configFile})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Configuration is valid")
+		return nil
+	},
+}
+
+func init() {
+	configCmd.PersistentFlags().String("config", "", "Path to the YAML config file (also CONFIG_FILE)")
+	configPrintCmd.Flags().String("format", "text", "Output format: text or json")
+
+	// Errors are printed once by main, usage is not useful for configuration problems
+	configPrintCmd.SilenceUsage = true
+	configPrintCmd.SilenceErrors = true
+	configValidateCmd.SilenceUsage = true
+	configValidateCmd.SilenceErrors = true
+
+	configCmd.AddCommand(configPrintCmd)
+	configCmd.AddCommand(configValidateCmd)
+}
@@ -1,90 +1,92 @@
 package db
 
 import (
-	"gorm.io/driver/sqlite"
+	"fmt"
+	"log/slog"
+
+	"github.com/jmaister/gots-template/config"
 	"gorm.io/gorm"
-	_ "modernc.org/sqlite" // Pure Go SQLite driver
 )
 
 var conn *gorm.DB
 
-func runMigrations(db *gorm.DB) error {
-	return db.AutoMigrate(&User{})
-}
+// Open opens the database configured in cfg without migrating it.
+// The dialect is selected from the database URL and the connection pool
+// uses the configured settings, falling back to the defaults of the dialect.
+// GORM logs to logger, with the slow query threshold of cfg, and traces the queries of traced requests.
+func Open(cfg config.DatabaseConfig, logger *slog.Logger) (*gorm.DB, error) {
+	target, err := cfg.Target()
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %w", err)
+	}
+
+	dialector, err := dialector(target)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: newGormLogger(logger, cfg.SlowQueryThreshold),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect database: %w", err)
+	}
 
-func Init() {
-	// Use modernc.org/sqlite driver (pure Go, no CGO required)
-	// Configure SQLite for better concurrent access and performance
-	dsn := "gots-template.db?" +
-		"_pragma=foreign_keys(1)&" +
-		"_pragma=journal_mode(WAL)&" +
-		"_pragma=synchronous(NORMAL)&" +
-		"_pragma=cache_size(1000)&" +
-		"_pragma=busy_timeout(30000)&" +
-		"_pragma=temp_store(memory)"
-
-	db, err := gorm.Open(sqlite.Dialector{
-		DriverName: "sqlite",
-		DSN:        dsn,
-	}, &gorm.Config{})
+	// Queries of traced requests become child spans of the request
+	err = db.Use(tracingPlugin{})
 	if err != nil {
-		panic("Failed to connect database: " + err.Error())
+		return nil, fmt.Errorf("failed to register the tracing plugin: %w", err)
 	}
 
 	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
-		panic("Failed to get underlying sql.DB: " + err.Error())
+		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
-	// Set connection pool settings
-	sqlDB.SetMaxOpenConns(10)
-	sqlDB.SetMaxIdleConns(5)
-	sqlDB.SetConnMaxLifetime(0) // No limit for SQLite
+	pool := poolSettings(target.Dialect, cfg)
+	sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
+	return db, nil
+}
+
+// Init opens the database configured in cfg and applies pending migrations
+func Init(cfg config.DatabaseConfig, logger *slog.Logger) error {
+	db, err := Open(cfg, logger)
+	if err != nil {
+		return err
+	}
 
 	// Migrate the schema
-	err2 := runMigrations(db)
-	if err2 != nil {
-		panic("Failed to migration DB: " + err2.Error())
+	err = runMigrations(db, logger)
+	if err != nil {
+		// The connection is not kept, release its pool
+		sqlDB, dbErr := db.DB()
+		if dbErr == nil {
+			sqlDB.Close()
+		}
+		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
 	conn = db
+	return nil
 }
 
+// InitForTest opens a shared in-memory database migrated with the same migrations as production
 func InitForTest() {
-	// Use modernc.org/sqlite driver for in-memory testing
-	// Configure SQLite for better concurrent access and performance
-	dsn := "file::memory:?cache=shared&" +
-		"_pragma=foreign_keys(1)&" +
-		"_pragma=journal_mode(WAL)&" +
-		"_pragma=synchronous(NORMAL)&" +
-		"_pragma=cache_size(1000)&" +
-		"_pragma=busy_timeout(30000)&" +
-		"_pragma=temp_store(memory)"
-
-	db, err := gorm.Open(sqlite.Dialector{
-		DriverName: "sqlite",
-		DSN:        dsn,
-	}, &gorm.Config{})
+	logger := slog.New(slog.DiscardHandler)
+	db, err := Open(config.DatabaseConfig{URL: "sqlite://" + config.SQLiteMemory}, logger)
 	if err != nil {
-		panic("Failed to connect database: " + err.Error())
+		panic(err.Error())
 	}
 
-	// Configure connection pool
-	sqlDB, err := db.DB()
-	if err != nil {
-		panic("Failed to get underlying sql.DB: " + err.Error())
-	}
-
-	// Set connection pool settings
-	sqlDB.SetMaxOpenConns(10)
-	sqlDB.SetMaxIdleConns(5)
-	sqlDB.SetConnMaxLifetime(0) // No limit for SQLite
-
 	// Migrate the schema
-	err2 := runMigrations(db)
-	if err2 != nil {
-		panic("Failed to migration DB: " + err2.Error())
+	err = runMigrations(db, logger)
+	if err != nil {
+		panic("Failed to migrate DB: " + err.Error())
 	}
 
 	conn = db
@@ -92,7 +94,23 @@ func InitForTest() {
 
 func GetConnection() *gorm.DB {
 	if conn == nil {
-		panic("Connection not initialized. Call db.Init() first.")
+		panic("Connection not initialized. Call db.Init(cfg, logger) first.")
 	}
 	return conn
 }
+
+// Close closes the underlying connection pool.
+// It is safe to call when the connection was never initialized.
+func Close() error {
+	if conn == nil {
+		return nil
+	}
+
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return err
+	}
+
+	conn = nil
+	return sqlDB.Close()
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmaister/gots-template/config"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	_ "modernc.org/sqlite" // Pure Go SQLite driver
+)
+
+// sqlitePragmas configure SQLite for better concurrent access and performance
+const sqlitePragmas = "_pragma=foreign_keys(1)&" +
+	"_pragma=journal_mode(WAL)&" +
+	"_pragma=synchronous(NORMAL)&" +
+	"_pragma=cache_size(1000)&" +
+	"_pragma=busy_timeout(30000)&" +
+	"_pragma=temp_store(memory)"
+
+// PoolSettings are the connection pool settings of a database/sql pool
+type PoolSettings struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// dialectPoolDefaults are used for every pool setting left at zero in the configuration
+var dialectPoolDefaults = map[config.DatabaseDialect]PoolSettings{
+	// No lifetime limit for SQLite, connections are local files
+	config.DialectSQLite: {MaxOpenConns: 10, MaxIdleConns: 5},
+	config.DialectPostgres: {
+		MaxOpenConns:    25,
+		MaxIdleConns:    10,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+	},
+	// Recycle before the server closes idle connections (wait_timeout)
+	config.DialectMySQL: {
+		MaxOpenConns:    25,
+		MaxIdleConns:    10,
+		ConnMaxLifetime: 5 * time.Minute,
+		ConnMaxIdleTime: 2 * time.Minute,
+	},
+}
+
+// poolSettings merges the configured pool settings with the defaults of the dialect
+func poolSettings(dialect config.DatabaseDialect, cfg config.DatabaseConfig) PoolSettings {
+	settings := dialectPoolDefaults[dialect]
+	if cfg.MaxOpenConns > 0 {
+		settings.MaxOpenConns = cfg.MaxOpenConns
+	}
+	if cfg.MaxIdleConns > 0 {
+		settings.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		settings.ConnMaxLifetime = cfg.ConnMaxLifetime
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		settings.ConnMaxIdleTime = cfg.ConnMaxIdleTime
+	}
+	return settings
+}
+
+// dialector returns the GORM dialector of a parsed database URL
+func dialector(target config.DatabaseTarget) (gorm.Dialector, error) {
+	switch target.Dialect {
+	case config.DialectSQLite:
+		// Use modernc.org/sqlite driver (pure Go, no CGO required)
+		return sqlite.Dialector{
+			DriverName: "sqlite",
+			DSN:        sqliteDSN(target.DSN),
+		}, nil
+	case config.DialectPostgres:
+		return postgres.Open(target.DSN), nil
+	case config.DialectMySQL:
+		return mysql.Open(target.DSN), nil
+	default:
+		return nil, fmt.Errorf("unsupported database dialect %q", target.Dialect)
+	}
+}
+
+// sqliteDSN adds the default pragmas to a SQLite path. Paths with their own
+// query parameters are used as they are.
+func sqliteDSN(path string) string {
+	if path == config.SQLiteMemory {
+		// Shared cache so every pooled connection sees the same in-memory database
+		return "file::memory:?cache=shared&" + sqlitePragmas
+	}
+	if strings.Contains(path, "?") {
+		return path
+	}
+	return path + "?" + sqlitePragmas
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolSettings(t *testing.T) {
+	t.Run("DialectDefaults", func(t *testing.T) {
+		settings := poolSettings(config.DialectPostgres, config.DatabaseConfig{})
+		assert.Equal(t, dialectPoolDefaults[config.DialectPostgres], settings)
+	})
+
+	t.Run("ConfiguredValuesOverrideDefaults", func(t *testing.T) {
+		settings := poolSettings(config.DialectMySQL, config.DatabaseConfig{
+			MaxOpenConns:    50,
+			ConnMaxLifetime: time.Hour,
+		})
+		assert.Equal(t, 50, settings.MaxOpenConns)
+		assert.Equal(t, time.Hour, settings.ConnMaxLifetime)
+		assert.Equal(t, dialectPoolDefaults[config.DialectMySQL].MaxIdleConns, settings.MaxIdleConns)
+	})
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	assert.Equal(t, "app.db?"+sqlitePragmas, sqliteDSN("app.db"))
+	assert.Equal(t, "app.db?_pragma=foreign_keys(1)", sqliteDSN("app.db?_pragma=foreign_keys(1)"))
+	assert.Equal(t, "file::memory:?cache=shared&"+sqlitePragmas, sqliteDSN(config.SQLiteMemory))
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("SQLiteInMemory", func(t *testing.T) {
+		db, err := Open(config.DatabaseConfig{URL: "sqlite://:memory:", MaxOpenConns: 3}, discardLogger)
+		assert.NoError(t, err)
+		assert.Equal(t, "sqlite", db.Dialector.Name())
+
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		defer sqlDB.Close()
+		assert.Equal(t, 3, sqlDB.Stats().MaxOpenConnections)
+	})
+
+	t.Run("InvalidURL", func(t *testing.T) {
+		_, err := Open(config.DatabaseConfig{URL: "mongodb://localhost/app"}, discardLogger)
+		assert.Error(t, err)
+	})
+}
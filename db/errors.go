@@ -0,0 +1,166 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Errors returned by every UserRepository implementation. Check them with errors.Is.
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write would break a unique constraint
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is returned when a record or query is invalid, see *ValidationError for the details
+	ErrValidation = errors.New("validation failed")
+)
+
+// FieldError describes why a single field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists the invalid fields of a record. It matches ErrValidation with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) add(field string, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		problems = append(problems, field.Field+" "+field.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(problems, ", ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// validateUser checks the fields every repository requires before writing a user
+func validateUser(user *User) error {
+	v := &ValidationError{}
+	if strings.TrimSpace(user.Email) == "" {
+		v.add("email", "is required")
+	} else if !strings.Contains(user.Email, "@") {
+		v.add("email", "must be a valid email address")
+	}
+	if strings.TrimSpace(user.Username) == "" {
+		v.add("username", "is required")
+	}
+
+	if len(v.Fields) > 0 {
+		return v
+	}
+	return nil
+}
+
+// userNotFound is the error returned when no user matches
+func userNotFound() error {
+	return fmt.Errorf("user %w", ErrNotFound)
+}
+
+// userConflict is the error returned when a unique user field is already taken
+func userConflict(field string) error {
+	if field == "" {
+		return fmt.Errorf("%w: user already exists", ErrConflict)
+	}
+	return fmt.Errorf("%w: user %s already exists", ErrConflict, field)
+}
+
+// uniqueViolationMarkers are the unique constraint error messages of each supported driver
+var uniqueViolationMarkers = []string{
+	"UNIQUE constraint failed", // SQLite
+	"duplicate key value",      // PostgreSQL (23505)
+	"Duplicate entry",          // MySQL (1062)
+}
+
+// translateUserError converts GORM and driver errors to the repository errors
+func translateUserError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return userNotFound()
+	}
+	if isUniqueViolation(err) {
+		return userConflict(uniqueViolationField(err.Error()))
+	}
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	message := err.Error()
+	for _, marker := range uniqueViolationMarkers {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueViolationField guesses the violated column from the driver message,
+// which names the column (SQLite) or the index (PostgreSQL, MySQL)
+func uniqueViolationField(message string) string {
+	message = strings.ToLower(message)
+	for _, field := range []string{"external_id", "email", "username"} {
+		if strings.Contains(message, field) {
+			return field
+		}
+	}
+	return ""
+}
+
+// validateRole checks the fields every repository requires before writing a role
+func validateRole(role *Role) error {
+	v := &ValidationError{}
+	if strings.TrimSpace(role.Name) == "" {
+		v.add("name", "is required")
+	}
+	for _, permission := range role.Permissions {
+		if strings.TrimSpace(permission.Permission) == "" {
+			v.add("permissions", "must not be empty")
+			break
+		}
+	}
+
+	if len(v.Fields) > 0 {
+		return v
+	}
+	return nil
+}
+
+// roleNotFound is the error returned when no role matches
+func roleNotFound() error {
+	return fmt.Errorf("role %w", ErrNotFound)
+}
+
+// roleConflict is the error returned when a role name is already taken
+func roleConflict() error {
+	return fmt.Errorf("%w: role name already exists", ErrConflict)
+}
+
+// translateRoleError converts GORM and driver errors to the repository errors
+func translateRoleError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return roleNotFound()
+	}
+	if isUniqueViolation(err) {
+		return roleConflict()
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotFoundErrors(t *testing.T) {
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			_, err := repo.GetByID(ctx, 999)
+			assert.ErrorIs(t, err, ErrNotFound)
+			_, err = repo.GetByEmail(ctx, "missing@example.com")
+			assert.ErrorIs(t, err, ErrNotFound)
+			_, err = repo.GetByUsername(ctx, "missing")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			missing := createTestUser("missing-" + name)
+			missing.ID = 999
+			err = repo.Update(ctx, missing)
+			assert.ErrorIs(t, err, ErrNotFound, "Update should not insert a missing user")
+			err = repo.Delete(ctx, 999)
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			_, err = repo.GetByID(ctx, 999)
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+func TestConflictErrors(t *testing.T) {
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			first := createTestUser("conflict-first-" + name)
+			err := repo.Create(ctx, first)
+			assert.NoError(t, err)
+			second := createTestUser("conflict-second-" + name)
+			err = repo.Create(ctx, second)
+			assert.NoError(t, err)
+
+			duplicate := createTestUser("conflict-dup-" + name)
+			duplicate.Email = first.Email
+			err = repo.Create(ctx, duplicate)
+			assert.ErrorIs(t, err, ErrConflict)
+			assert.EqualError(t, err, "conflict: user email already exists")
+
+			second.Username = first.Username
+			err = repo.Update(ctx, second)
+			assert.ErrorIs(t, err, ErrConflict)
+			assert.EqualError(t, err, "conflict: user username already exists")
+		})
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			err := repo.Create(context.Background(), &User{Email: "not-an-email"})
+			assert.ErrorIs(t, err, ErrValidation)
+
+			var validation *ValidationError
+			assert.True(t, errors.As(err, &validation))
+			assert.Equal(t, []FieldError{
+				{Field: "email", Message: "must be a valid email address"},
+				{Field: "username", Message: "is required"},
+			}, validation.Fields)
+
+			_, err = repo.List(context.Background(), UserQuery{SortBy: "password"})
+			assert.ErrorIs(t, err, ErrValidation)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// gormLogger writes the GORM logs to a slog.Logger. Queries are logged at debug level,
+// slow queries as warnings and failed queries as errors, queries canceled by the caller at
+// debug level. The SQL keeps its placeholders and errors lose the values drivers quote, the
+// bound values may be personal data.
+// The source of each record is the repository method that ran the query, not GORM itself.
+type gormLogger struct {
+	logger        *slog.Logger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+// newGormLogger bridges GORM to logger. Queries slower than slowThreshold are warnings, 0 disables it.
+func newGormLogger(logger *slog.Logger, slowThreshold time.Duration) gormlogger.Interface {
+	return &gormLogger{
+		logger:        logger,
+		level:         gormlogger.Info,
+		slowThreshold: slowThreshold,
+	}
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.log(ctx, utils.CallerFrame().PC, slog.LevelInfo, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.log(ctx, utils.CallerFrame().PC, slog.LevelWarn, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.log(ctx, utils.CallerFrame().PC, slog.LevelError, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	// The client went away, the query did not fail
+	case errors.Is(err, context.Canceled) && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		l.log(ctx, utils.CallerFrame().PC, slog.LevelDebug, "Query canceled", "sql", sql, "rows", rows, "duration", elapsed)
+	// Not found is an expected result, the repositories translate it
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.log(ctx, utils.CallerFrame().PC, slog.LevelError, "Query failed", "sql", sql, "rows", rows, "duration", elapsed, "error", queryError(err))
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		l.log(ctx, utils.CallerFrame().PC, slog.LevelWarn, "Slow query", "sql", sql, "rows", rows, "duration", elapsed, "threshold", l.slowThreshold)
+	case l.level >= gormlogger.Info && l.logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		l.log(ctx, utils.CallerFrame().PC, slog.LevelDebug, "Query", "sql", sql, "rows", rows, "duration", elapsed)
+	}
+}
+
+// queryError describes the error of a failed query without the values PostgreSQL and MySQL quote in
+// their messages, such as the duplicate email of a unique violation. Their errors are reduced to
+// the error code and the names of the objects involved.
+func queryError(err error) string {
+	var pgErr *pgconn.PgError
+	var mysqlErr *mysql.MySQLError
+	switch {
+	case errors.As(err, &pgErr):
+		description := "SQLSTATE " + pgErr.Code
+		if pgErr.TableName != "" {
+			description += " table " + pgErr.TableName
+		}
+		if pgErr.ColumnName != "" {
+			description += " column " + pgErr.ColumnName
+		}
+		if pgErr.ConstraintName != "" {
+			description += " constraint " + pgErr.ConstraintName
+		}
+		return description
+	case errors.As(err, &mysqlErr):
+		if mysqlErr.SQLState == [5]byte{} {
+			return fmt.Sprintf("MySQL error %d", mysqlErr.Number)
+		}
+		return fmt.Sprintf("MySQL error %d (SQLSTATE %s)", mysqlErr.Number, mysqlErr.SQLState[:])
+	default:
+		// SQLite messages name the columns, not the values
+		return err.Error()
+	}
+}
+
+// ParamsFilter drops the bound values, so the SQL passed to Trace keeps its placeholders
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// log writes a record with the program counter of its source. utils.CallerFrame must be
+// called by the methods of the interface, it skips their frame to find the caller of GORM.
+func (l *gormLogger) log(ctx context.Context, pc uintptr, level slog.Level, msg string, args ...interface{}) {
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	record := slog.NewRecord(time.Now(), level, msg, pc)
+	record.Add(args...)
+	_ = l.logger.Handler().Handle(ctx, record)
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmaister/gots-template/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// discardLogger is the logger of the databases opened by tests
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// logRecords decodes the JSON lines written by a slog.JSONHandler
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		err := json.Unmarshal([]byte(line), &record)
+		assert.NoError(t, err)
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestGormLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug, AddSource: true}))
+
+	open := func(t *testing.T, slowThreshold time.Duration) *UserRepositoryDB {
+		db, err := Open(config.DatabaseConfig{URL: "sqlite://" + filepath.Join(t.TempDir(), "logger.db"), SlowQueryThreshold: slowThreshold}, logger)
+		assert.NoError(t, err)
+		t.Cleanup(func() {
+			sqlDB, _ := db.DB()
+			sqlDB.Close()
+		})
+		err = runMigrations(db, discardLogger)
+		assert.NoError(t, err)
+		buf.Reset()
+		return NewDBUserRepository(db)
+	}
+
+	t.Run("Query", func(t *testing.T) {
+		repo := open(t, 0)
+		_, err := repo.GetByID(t.Context(), 1)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		records := logRecords(t, &buf)
+		assert.Len(t, records, 1, "Not found is not an error")
+		assert.Equal(t, "DEBUG", records[0]["level"])
+		assert.Equal(t, "Query", records[0]["msg"])
+		assert.Contains(t, records[0]["sql"], "FROM `users`")
+		source := records[0]["source"].(map[string]interface{})
+		assert.Equal(t, "userrepository_db.go", filepath.Base(source["file"].(string)), "The source is the repository, not GORM")
+	})
+
+	t.Run("Parameterized", func(t *testing.T) {
+		repo := open(t, 0)
+		_, err := repo.GetByEmail(t.Context(), "jane@example.com")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		records := logRecords(t, &buf)
+		assert.Len(t, records, 1)
+		assert.Contains(t, records[0]["sql"], "?")
+		assert.NotContains(t, records[0]["sql"], "jane@example.com", "Bound values are not logged")
+	})
+
+	t.Run("SlowQuery", func(t *testing.T) {
+		repo := open(t, time.Nanosecond)
+		_, err := repo.GetByID(t.Context(), 1)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		records := logRecords(t, &buf)
+		assert.Len(t, records, 1)
+		assert.Equal(t, "WARN", records[0]["level"])
+		assert.Equal(t, "Slow query", records[0]["msg"])
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		repo := open(t, 0)
+		err := repo.db.Exec("SELECT * FROM missing_table").Error
+		assert.Error(t, err)
+
+		records := logRecords(t, &buf)
+		assert.Len(t, records, 1)
+		assert.Equal(t, "ERROR", records[0]["level"])
+		assert.Equal(t, "Query failed", records[0]["msg"])
+		assert.Contains(t, records[0]["error"], "missing_table")
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		repo := open(t, 0)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		_, err := repo.GetByID(ctx, 1)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		records := logRecords(t, &buf)
+		assert.Len(t, records, 1)
+		assert.Equal(t, "DEBUG", records[0]["level"])
+		assert.Equal(t, "Query canceled", records[0]["msg"])
+	})
+
+	t.Run("UniqueViolation", func(t *testing.T) {
+		repo := open(t, 0)
+		err := repo.Create(t.Context(), &User{Email: "jane@example.com", Username: "jane"})
+		assert.NoError(t, err)
+		buf.Reset()
+		err = repo.Create(t.Context(), &User{Email: "jane@example.com", Username: "jane2"})
+		assert.ErrorIs(t, err, ErrConflict)
+
+		records := logRecords(t, &buf)
+		assert.Len(t, records, 1)
+		assert.Equal(t, "Query failed", records[0]["msg"])
+		assert.Contains(t, records[0]["error"], "users.email")
+		assert.NotContains(t, buf.String(), "jane@example.com")
+	})
+
+	t.Run("LevelFiltered", func(t *testing.T) {
+		logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		repo := open(t, 0)
+		_, err := repo.GetByID(t.Context(), 1)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestQueryError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			"PostgreSQL",
+			&pgconn.PgError{
+				Severity:       "ERROR",
+				Code:           "23505",
+				Message:        `duplicate key value violates unique constraint "idx_users_email"`,
+				Detail:         "Key (email)=(jane@example.com) already exists.",
+				TableName:      "users",
+				ConstraintName: "idx_users_email",
+			},
+			"SQLSTATE 23505 table users constraint idx_users_email",
+		},
+		{
+			"MySQL",
+			&mysql.MySQLError{Number: 1062, SQLState: [5]byte{'2', '3', '0', '0', '0'}, Message: "Duplicate entry 'jane@example.com' for key 'users.email'"},
+			"MySQL error 1062 (SQLSTATE 23000)",
+		},
+		{"Wrapped", fmt.Errorf("create user: %w", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'jane@example.com'"}), "MySQL error 1062"},
+		{"Other", errors.New("no such table: missing_table"), "no such table: missing_table"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, queryError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sqlMigrationFiles holds the SQL migrations, named <version>_<name>.up.sql and <version>_<name>.down.sql.
+// A file named <version>_<name>.up.<dialect>.sql replaces the generic one on that dialect (sqlite, postgres, mysql).
+//
+//go:embed migrations/*.sql
+var sqlMigrationFiles embed.FS
+
+// ErrMigrationChecksumMismatch is returned when an applied migration was edited afterwards
+var ErrMigrationChecksumMismatch = errors.New("migration checksum mismatch")
+
+// ErrUnknownMigration is returned when the database has a migration this binary does not know,
+// usually because it was migrated by a newer version of the application
+var ErrUnknownMigration = errors.New("unknown migration applied to database")
+
+// ErrPendingMigration is returned by Verify when a migration of this binary is not applied yet
+var ErrPendingMigration = errors.New("pending migration")
+
+// Migration is a single versioned, reversible schema change.
+// It is either written in Go (Up/Down) or in SQL (UpSQL/DownSQL), not both.
+type Migration struct {
+	Version uint
+	Name    string
+
+	Up   func(tx *gorm.DB) error
+	Down func(tx *gorm.DB) error
+	// Revision stands for the code of Up and Down in the checksum, which cannot hash Go code.
+	// It starts at 0; increment it whenever Up or Down change, so databases that applied the
+	// previous code detect the edit.
+	Revision uint
+
+	UpSQL   string
+	DownSQL string
+}
+
+// Checksum identifies the content of the migration to detect edits after it was applied.
+// SQL migrations are hashed by their statements; Go migrations by their version, name and Revision.
+func (m Migration) Checksum() string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\x00%s\x00%s\x00%s", m.Version, m.Name, m.UpSQL, m.DownSQL)
+	if m.Revision > 0 {
+		// Revision 0 keeps the checksums recorded before revisions existed
+		fmt.Fprintf(hash, "\x00%d", m.Revision)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (m Migration) up(tx *gorm.DB) error {
+	if m.Up != nil {
+		return m.Up(tx)
+	}
+	return tx.Exec(m.UpSQL).Error
+}
+
+func (m Migration) down(tx *gorm.DB) error {
+	if m.Down != nil {
+		return m.Down(tx)
+	}
+	if m.DownSQL == "" {
+		return fmt.Errorf("migration %d_%s is not reversible", m.Version, m.Name)
+	}
+	return tx.Exec(m.DownSQL).Error
+}
+
+// SchemaMigration is a row of the schema_migrations tracking table
+type SchemaMigration struct {
+	Version   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"not null"`
+	Checksum  string `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+// TableName sets the tracking table name
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus describes a known migration and whether it is applied
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Migrator applies and rolls back migrations, tracking them in schema_migrations
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	// checksums are those of the migrations by version, computed once
+	checksums map[uint]string
+	logger    *slog.Logger
+}
+
+// NewMigrator creates a migrator for the given migrations, which are sorted by version.
+// Every applied and rolled back migration is logged to logger.
+func NewMigrator(db *gorm.DB, migrations []Migration, logger *slog.Logger) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	checksums := make(map[uint]string, len(sorted))
+	for _, migration := range sorted {
+		checksums[migration.Version] = migration.Checksum()
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: sorted,
+		checksums:  checksums,
+		logger:     logger,
+	}
+}
+
+// Up applies every pending migration in version order, each one in its own transaction.
+// It refuses to run when an applied migration was edited or is unknown.
+func (m *Migrator) Up() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		m.logger.Info("Applying migration", "version", migration.Version, "name", migration.Name)
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			err := migration.up(tx)
+			if err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Checksum:  migration.Checksum(),
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// Down rolls back the last steps applied migrations, newest first, each one in its own transaction
+func (m *Migrator) Down(steps int) error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		m.logger.Info("Rolling back migration", "version", migration.Version, "name", migration.Name)
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			err := migration.down(tx)
+			if err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("rollback of migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		steps--
+	}
+	return nil
+}
+
+// Status lists every known migration and whether it is applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		row, ok := applied[migration.Version]
+		if ok {
+			status.Applied = true
+			status.AppliedAt = &row.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Verify checks that every migration is applied and unchanged, without writing to the database.
+// It is the schema check of the readiness probe.
+func (m *Migrator) Verify(ctx context.Context) error {
+	applied, err := m.readApplied(m.db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			return fmt.Errorf("%w: %d_%s", ErrPendingMigration, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// applied creates the tracking table if needed and returns the applied migrations by version,
+// verifying that each one is known and unchanged
+func (m *Migrator) applied() (map[uint]SchemaMigration, error) {
+	err := m.db.AutoMigrate(&SchemaMigration{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return m.readApplied(m.db)
+}
+
+// readApplied returns the applied migrations by version, verifying that each one is known and unchanged
+func (m *Migrator) readApplied(db *gorm.DB) (map[uint]SchemaMigration, error) {
+	var rows []SchemaMigration
+	err := db.Order("version").Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	applied := make(map[uint]SchemaMigration, len(rows))
+	for _, row := range rows {
+		checksum, ok := m.checksums[row.Version]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d_%s", ErrUnknownMigration, row.Version, row.Name)
+		}
+		if checksum != row.Checksum {
+			return nil, fmt.Errorf("%w: %d_%s was modified after being applied", ErrMigrationChecksumMismatch, row.Version, row.Name)
+		}
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Migrations returns every migration of the application for a dialect, Go and SQL, sorted by version
+func Migrations(dialect string) ([]Migration, error) {
+	sqlMigrations, err := loadSQLMigrations(sqlMigrationFiles, "migrations", dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append([]Migration{}, goMigrations...)
+	all = append(all, sqlMigrations...)
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Version < all[j].Version
+	})
+
+	for i := 1; i < len(all); i++ {
+		if all[i].Version == all[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", all[i].Version, all[i-1].Name, all[i].Name)
+		}
+	}
+	return all, nil
+}
+
+// sqlMigrationFile is the SQL of one direction of a migration
+type sqlMigrationFile struct {
+	generic string
+	dialect string
+}
+
+// sql returns the dialect specific SQL when there is one
+func (f sqlMigrationFile) sql() string {
+	if f.dialect != "" {
+		return f.dialect
+	}
+	return f.generic
+}
+
+// loadSQLMigrations reads <version>_<name>.<up|down>[.<dialect>].sql files from dir.
+// Files for other dialects are ignored.
+func loadSQLMigrations(fsys fs.FS, dir string, dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[uint]string)
+	ups := make(map[uint]*sqlMigrationFile)
+	downs := make(map[uint]*sqlMigrationFile)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(fileName, ".sql")
+		direction := path.Ext(base)
+		fileDialect := ""
+		if direction != ".up" && direction != ".down" && direction != "" {
+			fileDialect = strings.TrimPrefix(direction, ".")
+			base = strings.TrimSuffix(base, direction)
+			direction = path.Ext(base)
+		}
+		base = strings.TrimSuffix(base, direction)
+		if direction != ".up" && direction != ".down" {
+			return nil, fmt.Errorf("migration file %s must end in .up.sql or .down.sql", fileName)
+		}
+
+		versionText, name, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(versionText, 10, 64)
+		if !ok || err != nil || name == "" {
+			return nil, fmt.Errorf("migration file %s must be named <version>_<name>%s.sql", fileName, direction)
+		}
+
+		existing, exists := names[uint(version)]
+		if exists && existing != name {
+			return nil, fmt.Errorf("migration version %d has two names: %s and %s", version, existing, name)
+		}
+		names[uint(version)] = name
+
+		if fileDialect != "" && fileDialect != dialect {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		files := ups
+		if direction == ".down" {
+			files = downs
+		}
+		file, ok := files[uint(version)]
+		if !ok {
+			file = &sqlMigrationFile{}
+			files[uint(version)] = file
+		}
+		if fileDialect != "" {
+			file.dialect = string(content)
+		} else {
+			file.generic = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	for version, name := range names {
+		up, ok := ups[version]
+		if !ok || up.sql() == "" {
+			return nil, fmt.Errorf("migration %d_%s has no .up.sql file for %s", version, name, dialect)
+		}
+
+		migration := Migration{
+			Version: version,
+			Name:    name,
+			UpSQL:   up.sql(),
+		}
+		down, ok := downs[version]
+		if ok {
+			migration.DownSQL = down.sql()
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// runMigrations applies every pending migration
+func runMigrations(db *gorm.DB, logger *slog.Logger) error {
+	migrations, err := Migrations(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	return NewMigrator(db, migrations, logger).Up()
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// goMigrations are the migrations written in Go. SQL migrations live in db/migrations.
+// Models used here are frozen copies, so later changes to the application models do not alter old migrations.
+// The checksum cannot see the Go code: increment Revision whenever Up or Down change.
+var goMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_users",
+		Up: func(tx *gorm.DB) error {
+			// Databases created before versioned migrations already have the table
+			if tx.Migrator().HasTable(&userV1{}) {
+				return nil
+			}
+			return tx.Migrator().CreateTable(&userV1{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&userV1{})
+		},
+	},
+	{
+		Version: 3,
+		Name:    "users_external_id",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().AddColumn(&userV3{}, "ExternalID")
+			if err != nil {
+				return err
+			}
+			err = tx.Migrator().AddColumn(&userV3{}, "Provider")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&userV3{}, "idx_users_external_id")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().DropIndex(&userV3{}, "idx_users_external_id")
+			if err != nil {
+				return err
+			}
+			// Plain ALTER TABLE: GORM recreates SQLite tables to drop columns, losing the other indexes
+			err = tx.Exec("ALTER TABLE users DROP COLUMN provider").Error
+			if err != nil {
+				return err
+			}
+			return tx.Exec("ALTER TABLE users DROP COLUMN external_id").Error
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create_roles",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&roleV4{}, &rolePermissionV4{}, &userRoleV4{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&userRoleV4{}, &rolePermissionV4{}, &roleV4{})
+		},
+	},
+}
+
+// userV1 is the users table as created by migration 1
+type userV1 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Email     string `gorm:"unique;not null"`
+	Username  string `gorm:"unique;not null"`
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (userV1) TableName() string {
+	return "users"
+}
+
+// userV3 is the users table after migration 3 added the gateway identity
+type userV3 struct {
+	ID         uint   `gorm:"primaryKey"`
+	Email      string `gorm:"unique;not null"`
+	Username   string `gorm:"unique;not null"`
+	Name       string
+	ExternalID *string `gorm:"uniqueIndex:idx_users_external_id"`
+	Provider   string  `gorm:"not null;default:''"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (userV3) TableName() string {
+	return "users"
+}
+
+// roleV4, rolePermissionV4 and userRoleV4 are the role tables as created by migration 4
+type roleV4 struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"unique;not null"`
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (roleV4) TableName() string {
+	return "roles"
+}
+
+type rolePermissionV4 struct {
+	RoleID     uint    `gorm:"primaryKey"`
+	Permission string  `gorm:"primaryKey"`
+	Role       *roleV4 `gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE"`
+}
+
+func (rolePermissionV4) TableName() string {
+	return "role_permissions"
+}
+
+type userRoleV4 struct {
+	UserID    uint    `gorm:"primaryKey"`
+	RoleID    uint    `gorm:"primaryKey"`
+	User      *userV3 `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Role      *roleV4 `gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time
+}
+
+func (userRoleV4) TableName() string {
+	return "user_roles"
+}
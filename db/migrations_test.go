@@ -0,0 +1,230 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupMigrationDB creates an empty file database so each test has its own schema
+func setupMigrationDB(t *testing.T) *gorm.DB {
+	db, err := Open(config.DatabaseConfig{URL: "sqlite://" + filepath.Join(t.TempDir(), "migrations.db")}, discardLogger)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+func newAppMigrator(t *testing.T, db *gorm.DB) *Migrator {
+	migrations, err := Migrations(db.Dialector.Name())
+	assert.NoError(t, err)
+	return NewMigrator(db, migrations, discardLogger)
+}
+
+func TestMigrationsUp(t *testing.T) {
+	db := setupMigrationDB(t)
+	migrator := newAppMigrator(t, db)
+
+	err := migrator.Up()
+	assert.NoError(t, err)
+	assert.True(t, db.Migrator().HasTable(&User{}))
+	assert.True(t, db.Migrator().HasIndex(&User{}, "idx_users_created_at"))
+	assert.True(t, db.Migrator().HasColumn(&User{}, "ExternalID"))
+	assert.True(t, db.Migrator().HasIndex(&User{}, "idx_users_external_id"))
+	assert.True(t, db.Migrator().HasTable(&Role{}))
+	assert.True(t, db.Migrator().HasTable(&RolePermission{}))
+	assert.True(t, db.Migrator().HasTable(&UserRole{}))
+
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, statuses)
+	for _, status := range statuses {
+		assert.True(t, status.Applied, "Migration %d should be applied", status.Version)
+		assert.NotNil(t, status.AppliedAt)
+	}
+
+	// Running again is a no-op
+	err = migrator.Up()
+	assert.NoError(t, err)
+
+	var count int64
+	db.Model(&SchemaMigration{}).Count(&count)
+	assert.Equal(t, int64(len(statuses)), count)
+}
+
+func TestMigrationsDown(t *testing.T) {
+	db := setupMigrationDB(t)
+	migrator := newAppMigrator(t, db)
+	assert.NoError(t, migrator.Up())
+
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+
+	// Roll back only the latest migration
+	err = migrator.Down(1)
+	assert.NoError(t, err)
+
+	after, err := migrator.Status()
+	assert.NoError(t, err)
+	assert.False(t, after[len(after)-1].Applied)
+	assert.True(t, after[0].Applied)
+
+	// Roll back everything, then apply again
+	err = migrator.Down(len(statuses))
+	assert.NoError(t, err)
+	assert.False(t, db.Migrator().HasTable(&User{}))
+
+	err = migrator.Up()
+	assert.NoError(t, err)
+	assert.True(t, db.Migrator().HasTable(&User{}))
+}
+
+func TestMigrationsChecksumMismatch(t *testing.T) {
+	db := setupMigrationDB(t)
+	migrator := newAppMigrator(t, db)
+	assert.NoError(t, migrator.Up())
+
+	// Simulate an applied migration that was edited afterwards
+	err := db.Model(&SchemaMigration{}).Where("version = ?", 2).Update("checksum", "edited").Error
+	assert.NoError(t, err)
+
+	err = migrator.Up()
+	assert.True(t, errors.Is(err, ErrMigrationChecksumMismatch), "Expected checksum mismatch, got %v", err)
+}
+
+func TestMigrationsUnknownVersion(t *testing.T) {
+	db := setupMigrationDB(t)
+	migrator := newAppMigrator(t, db)
+	assert.NoError(t, migrator.Up())
+
+	// A database migrated by a newer binary
+	err := db.Create(&SchemaMigration{Version: 9999, Name: "from_the_future", Checksum: "x"}).Error
+	assert.NoError(t, err)
+
+	err = migrator.Up()
+	assert.True(t, errors.Is(err, ErrUnknownMigration), "Expected unknown migration, got %v", err)
+}
+
+func TestMigrationsVerify(t *testing.T) {
+	db := setupMigrationDB(t)
+	migrator := newAppMigrator(t, db)
+
+	err := migrator.Verify(t.Context())
+	assert.Error(t, err, "Nothing is migrated")
+	assert.False(t, db.Migrator().HasTable(&SchemaMigration{}), "Verify does not write")
+
+	assert.NoError(t, migrator.Up())
+	assert.NoError(t, migrator.Verify(t.Context()))
+
+	assert.NoError(t, migrator.Down(1))
+	err = migrator.Verify(t.Context())
+	assert.True(t, errors.Is(err, ErrPendingMigration), "Expected pending migration, got %v", err)
+}
+
+func TestMigrationsAreTransactional(t *testing.T) {
+	db := setupMigrationDB(t)
+	migrator := NewMigrator(db, []Migration{
+		{
+			Version: 1,
+			Name:    "half_done",
+			UpSQL:   "CREATE TABLE half_done (id INTEGER PRIMARY KEY); INSERT INTO missing_table VALUES (1);",
+			DownSQL: "DROP TABLE half_done;",
+		},
+	}, discardLogger)
+
+	err := migrator.Up()
+	assert.Error(t, err)
+	assert.False(t, db.Migrator().HasTable("half_done"), "Failed migration should be rolled back")
+
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	assert.False(t, statuses[0].Applied)
+}
+
+func TestLoadSQLMigrations(t *testing.T) {
+	t.Run("PairsUpAndDownFiles", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/0003_add_things.up.sql":   {Data: []byte("CREATE TABLE things (id INTEGER);")},
+			"m/0003_add_things.down.sql": {Data: []byte("DROP TABLE things;")},
+			"m/0004_no_down.up.sql":      {Data: []byte("SELECT 1;")},
+		}
+
+		migrations, err := loadSQLMigrations(fsys, "m", "sqlite")
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 2)
+
+		byVersion := map[uint]Migration{}
+		for _, m := range migrations {
+			byVersion[m.Version] = m
+		}
+		assert.Equal(t, "add_things", byVersion[3].Name)
+		assert.Equal(t, "DROP TABLE things;", byVersion[3].DownSQL)
+		assert.Empty(t, byVersion[4].DownSQL)
+	})
+
+	t.Run("DialectOverrides", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/0003_idx.up.sql":         {Data: []byte("CREATE INDEX idx ON t (c);")},
+			"m/0003_idx.down.sql":       {Data: []byte("DROP INDEX idx;")},
+			"m/0003_idx.down.mysql.sql": {Data: []byte("DROP INDEX idx ON t;")},
+			"m/0004_pg.up.postgres.sql": {Data: []byte("CREATE EXTENSION citext;")},
+		}
+
+		migrations, err := loadSQLMigrations(fsys, "m", "mysql")
+		assert.Error(t, err, "0004 has no generic or mysql up file")
+
+		delete(fsys, "m/0004_pg.up.postgres.sql")
+		migrations, err = loadSQLMigrations(fsys, "m", "mysql")
+		assert.NoError(t, err)
+		assert.Equal(t, "DROP INDEX idx ON t;", migrations[0].DownSQL)
+
+		migrations, err = loadSQLMigrations(fsys, "m", "sqlite")
+		assert.NoError(t, err)
+		assert.Equal(t, "DROP INDEX idx;", migrations[0].DownSQL)
+	})
+
+	t.Run("RejectsBadNames", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/create_things.up.sql": {Data: []byte("SELECT 1;")},
+		}
+		_, err := loadSQLMigrations(fsys, "m", "sqlite")
+		assert.Error(t, err)
+	})
+
+	t.Run("RejectsMissingUp", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/0005_only_down.down.sql": {Data: []byte("SELECT 1;")},
+		}
+		_, err := loadSQLMigrations(fsys, "m", "sqlite")
+		assert.Error(t, err)
+	})
+}
+
+func TestMigrationChecksum(t *testing.T) {
+	original := Migration{Version: 1, Name: "a", UpSQL: "CREATE TABLE a (id INTEGER);"}
+	edited := original
+	edited.UpSQL = "CREATE TABLE a (id INTEGER, name TEXT);"
+
+	assert.Equal(t, original.Checksum(), original.Checksum())
+	assert.NotEqual(t, original.Checksum(), edited.Checksum())
+
+	t.Run("GoRevision", func(t *testing.T) {
+		original := Migration{Version: 1, Name: "a", Up: func(tx *gorm.DB) error { return nil }}
+		revised := original
+		revised.Revision = 1
+		assert.NotEqual(t, original.Checksum(), revised.Checksum(), "Go migrations are edited by bumping their revision")
+
+		// Revision 0 hashes like migrations recorded before revisions existed
+		hash := sha256.Sum256([]byte("1\x00a\x00\x00"))
+		assert.Equal(t, hex.EncodeToString(hash[:]), original.Checksum())
+	})
+}
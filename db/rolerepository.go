@@ -0,0 +1,29 @@
+package db
+
+import "context"
+
+// Built-in role names. RoleAdmin is implied by the gateway admin flag and
+// RoleAuthenticated by any authenticated session, neither needs to be stored.
+const (
+	RoleAdmin         = "admin"
+	RoleAuthenticated = "authenticated"
+)
+
+// RoleRepository interface for abstracting role and permission storage.
+// Roles are addressed by name, the name is what policies and the CLI refer to.
+type RoleRepository interface {
+	// CreateRole creates a role together with its permissions
+	CreateRole(ctx context.Context, role *Role) error
+	GetRoleByName(ctx context.Context, name string) (*Role, error)
+	ListRoles(ctx context.Context) ([]*Role, error)
+	// DeleteRole deletes a role, its permissions and its assignments
+	DeleteRole(ctx context.Context, name string) error
+	// GrantPermission adds a permission to a role, granting it twice is not an error
+	GrantPermission(ctx context.Context, roleName string, permission string) error
+	RevokePermission(ctx context.Context, roleName string, permission string) error
+	// AssignRole assigns a role to a user, assigning it twice is not an error
+	AssignRole(ctx context.Context, userID uint, roleName string) error
+	UnassignRole(ctx context.Context, userID uint, roleName string) error
+	// GetUserRoles returns the roles assigned to a user with their permissions
+	GetUserRoles(ctx context.Context, userID uint) ([]*Role, error)
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleRepositoryDB implements RoleRepository with a GORM database connection
+type RoleRepositoryDB struct {
+	db *gorm.DB
+}
+
+// NewDBRoleRepository creates a new database-backed role repository
+func NewDBRoleRepository(db *gorm.DB) *RoleRepositoryDB {
+	return &RoleRepositoryDB{
+		db: db,
+	}
+}
+
+// CreateRole creates a role together with its permissions
+func (r *RoleRepositoryDB) CreateRole(ctx context.Context, role *Role) error {
+	err := validateRole(role)
+	if err != nil {
+		return err
+	}
+
+	result := r.db.WithContext(ctx).Create(role)
+	return translateRoleError(result.Error)
+}
+
+// GetRoleByName finds a role by name
+func (r *RoleRepositoryDB) GetRoleByName(ctx context.Context, name string) (*Role, error) {
+	var role Role
+	result := r.db.WithContext(ctx).Preload("Permissions").First(&role, "name = ?", name)
+	if result.Error != nil {
+		return nil, translateRoleError(result.Error)
+	}
+	return &role, nil
+}
+
+// ListRoles retrieves all roles ordered by name
+func (r *RoleRepositoryDB) ListRoles(ctx context.Context) ([]*Role, error) {
+	var roles []*Role
+	result := r.db.WithContext(ctx).Preload("Permissions").Order("name").Find(&roles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return roles, nil
+}
+
+// DeleteRole deletes a role, its permissions and assignments are removed by the foreign keys
+func (r *RoleRepositoryDB) DeleteRole(ctx context.Context, name string) error {
+	result := r.db.WithContext(ctx).Delete(&Role{}, "name = ?", name)
+	if result.Error != nil {
+		return translateRoleError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return roleNotFound()
+	}
+	return nil
+}
+
+// GrantPermission adds a permission to a role
+func (r *RoleRepositoryDB) GrantPermission(ctx context.Context, roleName string, permission string) error {
+	if strings.TrimSpace(permission) == "" {
+		return &ValidationError{Fields: []FieldError{{Field: "permission", Message: "is required"}}}
+	}
+	role, err := r.GetRoleByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	rolePermission := &RolePermission{RoleID: role.ID, Permission: permission}
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(rolePermission)
+	return translateRoleError(result.Error)
+}
+
+// RevokePermission removes a permission from a role
+func (r *RoleRepositoryDB) RevokePermission(ctx context.Context, roleName string, permission string) error {
+	role, err := r.GetRoleByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	result := r.db.WithContext(ctx).Delete(&RolePermission{}, "role_id = ? AND permission = ?", role.ID, permission)
+	return translateRoleError(result.Error)
+}
+
+// AssignRole assigns a role to a user
+func (r *RoleRepositoryDB) AssignRole(ctx context.Context, userID uint, roleName string) error {
+	role, err := r.GetRoleByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	// Checked first so an unknown user is reported as not found instead of a foreign key error
+	result := r.db.WithContext(ctx).Select("id").First(&User{}, userID)
+	if result.Error != nil {
+		return translateUserError(result.Error)
+	}
+
+	userRole := &UserRole{UserID: userID, RoleID: role.ID}
+	result = r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(userRole)
+	return translateRoleError(result.Error)
+}
+
+// UnassignRole removes a role from a user
+func (r *RoleRepositoryDB) UnassignRole(ctx context.Context, userID uint, roleName string) error {
+	role, err := r.GetRoleByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	result := r.db.WithContext(ctx).Delete(&UserRole{}, "user_id = ? AND role_id = ?", userID, role.ID)
+	return translateRoleError(result.Error)
+}
+
+// GetUserRoles returns the roles assigned to a user with their permissions
+func (r *RoleRepositoryDB) GetUserRoles(ctx context.Context, userID uint) ([]*Role, error) {
+	var roles []*Role
+	result := r.db.WithContext(ctx).
+		Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return roles, nil
+}
@@ -0,0 +1,242 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RoleRepositoryMemory implements RoleRepository using in-memory storage.
+// It does not know the users, so AssignRole accepts any user ID.
+type RoleRepositoryMemory struct {
+	roles map[uint]*Role
+	// assignments holds the role IDs of each user ID
+	assignments map[uint]map[uint]bool
+	mu          sync.RWMutex
+	id          uint
+}
+
+// NewMemoryRoleRepository creates a new memory-backed role repository
+func NewMemoryRoleRepository() *RoleRepositoryMemory {
+	return &RoleRepositoryMemory{
+		roles:       make(map[uint]*Role),
+		assignments: make(map[uint]map[uint]bool),
+	}
+}
+
+// CreateRole creates a role together with its permissions
+func (r *RoleRepositoryMemory) CreateRole(ctx context.Context, role *Role) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	err = validateRole(role)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.findRole(role.Name) != nil {
+		return roleConflict()
+	}
+
+	// Set timestamps like GORM does
+	now := time.Now()
+	if role.CreatedAt.IsZero() {
+		role.CreatedAt = now
+	}
+	role.UpdatedAt = now
+
+	r.id++
+	role.ID = r.id
+	for i := range role.Permissions {
+		role.Permissions[i].RoleID = role.ID
+	}
+	r.roles[role.ID] = copyRole(role)
+	return nil
+}
+
+// GetRoleByName finds a role by name
+func (r *RoleRepositoryMemory) GetRoleByName(ctx context.Context, name string) (*Role, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	role := r.findRole(name)
+	if role == nil {
+		return nil, roleNotFound()
+	}
+	return copyRole(role), nil
+}
+
+// ListRoles retrieves all roles ordered by name
+func (r *RoleRepositoryMemory) ListRoles(ctx context.Context) ([]*Role, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	roles := make([]*Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		roles = append(roles, copyRole(role))
+	}
+	sortRoles(roles)
+	return roles, nil
+}
+
+// DeleteRole deletes a role, its permissions and its assignments
+func (r *RoleRepositoryMemory) DeleteRole(ctx context.Context, name string) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role := r.findRole(name)
+	if role == nil {
+		return roleNotFound()
+	}
+	delete(r.roles, role.ID)
+	for _, roleIDs := range r.assignments {
+		delete(roleIDs, role.ID)
+	}
+	return nil
+}
+
+// GrantPermission adds a permission to a role
+func (r *RoleRepositoryMemory) GrantPermission(ctx context.Context, roleName string, permission string) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(permission) == "" {
+		return &ValidationError{Fields: []FieldError{{Field: "permission", Message: "is required"}}}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role := r.findRole(roleName)
+	if role == nil {
+		return roleNotFound()
+	}
+	if !slices.Contains(role.PermissionNames(), permission) {
+		role.Permissions = append(role.Permissions, RolePermission{RoleID: role.ID, Permission: permission})
+	}
+	return nil
+}
+
+// RevokePermission removes a permission from a role
+func (r *RoleRepositoryMemory) RevokePermission(ctx context.Context, roleName string, permission string) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role := r.findRole(roleName)
+	if role == nil {
+		return roleNotFound()
+	}
+	role.Permissions = slices.DeleteFunc(role.Permissions, func(p RolePermission) bool {
+		return p.Permission == permission
+	})
+	return nil
+}
+
+// AssignRole assigns a role to a user
+func (r *RoleRepositoryMemory) AssignRole(ctx context.Context, userID uint, roleName string) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role := r.findRole(roleName)
+	if role == nil {
+		return roleNotFound()
+	}
+	if r.assignments[userID] == nil {
+		r.assignments[userID] = make(map[uint]bool)
+	}
+	r.assignments[userID][role.ID] = true
+	return nil
+}
+
+// UnassignRole removes a role from a user
+func (r *RoleRepositoryMemory) UnassignRole(ctx context.Context, userID uint, roleName string) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role := r.findRole(roleName)
+	if role == nil {
+		return roleNotFound()
+	}
+	delete(r.assignments[userID], role.ID)
+	return nil
+}
+
+// GetUserRoles returns the roles assigned to a user with their permissions
+func (r *RoleRepositoryMemory) GetUserRoles(ctx context.Context, userID uint) ([]*Role, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	roles := make([]*Role, 0, len(r.assignments[userID]))
+	for roleID := range r.assignments[userID] {
+		roles = append(roles, copyRole(r.roles[roleID]))
+	}
+	sortRoles(roles)
+	return roles, nil
+}
+
+// findRole returns the stored role with a name, or nil. The caller must hold the lock.
+func (r *RoleRepositoryMemory) findRole(name string) *Role {
+	for _, role := range r.roles {
+		if role.Name == name {
+			return role
+		}
+	}
+	return nil
+}
+
+func sortRoles(roles []*Role) {
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+}
+
+// copyRole returns a copy so callers cannot change stored roles
+func copyRole(role *Role) *Role {
+	copied := *role
+	copied.Permissions = slices.Clone(role.Permissions)
+	return &copied
+}
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// roleRepositories returns an empty role repository for every implementation,
+// with the ID of a user that roles can be assigned to
+func roleRepositories(t *testing.T) map[string]struct {
+	repo   RoleRepository
+	userID uint
+} {
+	db := setupTestDB(t)
+	user := createTestUser("roles")
+	err := NewDBUserRepository(db).Create(context.Background(), user)
+	assert.NoError(t, err)
+
+	return map[string]struct {
+		repo   RoleRepository
+		userID uint
+	}{
+		"DB":     {repo: NewDBRoleRepository(db), userID: user.ID},
+		"Memory": {repo: NewMemoryRoleRepository(), userID: 1},
+	}
+}
+
+func TestCreateRole(t *testing.T) {
+	for name, tc := range roleRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			role := &Role{
+				Name:        "editor",
+				Description: "Edits users",
+				Permissions: []RolePermission{{Permission: "users:read"}, {Permission: "users:write"}},
+			}
+			err := tc.repo.CreateRole(ctx, role)
+			assert.NoError(t, err)
+			assert.NotZero(t, role.ID)
+
+			found, err := tc.repo.GetRoleByName(ctx, "editor")
+			assert.NoError(t, err)
+			assert.Equal(t, "Edits users", found.Description)
+			assert.ElementsMatch(t, []string{"users:read", "users:write"}, found.PermissionNames())
+
+			err = tc.repo.CreateRole(ctx, &Role{Name: "editor"})
+			assert.ErrorIs(t, err, ErrConflict)
+
+			err = tc.repo.CreateRole(ctx, &Role{Name: " "})
+			assert.ErrorIs(t, err, ErrValidation)
+
+			_, err = tc.repo.GetRoleByName(ctx, "unknown")
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+func TestGrantAndRevokePermission(t *testing.T) {
+	for name, tc := range roleRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			err := tc.repo.CreateRole(ctx, &Role{Name: "viewer"})
+			assert.NoError(t, err)
+
+			err = tc.repo.GrantPermission(ctx, "viewer", "users:read")
+			assert.NoError(t, err)
+			// Granting twice is not an error
+			err = tc.repo.GrantPermission(ctx, "viewer", "users:read")
+			assert.NoError(t, err)
+
+			role, err := tc.repo.GetRoleByName(ctx, "viewer")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"users:read"}, role.PermissionNames())
+
+			err = tc.repo.RevokePermission(ctx, "viewer", "users:read")
+			assert.NoError(t, err)
+			role, err = tc.repo.GetRoleByName(ctx, "viewer")
+			assert.NoError(t, err)
+			assert.Empty(t, role.PermissionNames())
+
+			err = tc.repo.GrantPermission(ctx, "unknown", "users:read")
+			assert.ErrorIs(t, err, ErrNotFound)
+			err = tc.repo.GrantPermission(ctx, "viewer", "")
+			assert.ErrorIs(t, err, ErrValidation)
+		})
+	}
+}
+
+func TestAssignRole(t *testing.T) {
+	for name, tc := range roleRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			err := tc.repo.CreateRole(ctx, &Role{Name: "viewer", Permissions: []RolePermission{{Permission: "users:read"}}})
+			assert.NoError(t, err)
+			err = tc.repo.CreateRole(ctx, &Role{Name: "editor"})
+			assert.NoError(t, err)
+
+			err = tc.repo.AssignRole(ctx, tc.userID, "viewer")
+			assert.NoError(t, err)
+			// Assigning twice is not an error
+			err = tc.repo.AssignRole(ctx, tc.userID, "viewer")
+			assert.NoError(t, err)
+			err = tc.repo.AssignRole(ctx, tc.userID, "editor")
+			assert.NoError(t, err)
+
+			roles, err := tc.repo.GetUserRoles(ctx, tc.userID)
+			assert.NoError(t, err)
+			assert.Len(t, roles, 2)
+			assert.Equal(t, "editor", roles[0].Name)
+			assert.Equal(t, "viewer", roles[1].Name)
+			assert.Equal(t, []string{"users:read"}, roles[1].PermissionNames())
+
+			err = tc.repo.UnassignRole(ctx, tc.userID, "editor")
+			assert.NoError(t, err)
+			roles, err = tc.repo.GetUserRoles(ctx, tc.userID)
+			assert.NoError(t, err)
+			assert.Len(t, roles, 1)
+
+			// Deleting a role removes its assignments
+			err = tc.repo.DeleteRole(ctx, "viewer")
+			assert.NoError(t, err)
+			roles, err = tc.repo.GetUserRoles(ctx, tc.userID)
+			assert.NoError(t, err)
+			assert.Empty(t, roles)
+
+			err = tc.repo.DeleteRole(ctx, "viewer")
+			assert.ErrorIs(t, err, ErrNotFound)
+			err = tc.repo.AssignRole(ctx, tc.userID, "unknown")
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+func TestAssignRoleUnknownUser(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewDBRoleRepository(db)
+
+	err := repo.CreateRole(context.Background(), &Role{Name: "viewer"})
+	assert.NoError(t, err)
+
+	err = repo.AssignRole(context.Background(), 999, "viewer")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDeleteUserRemovesRoleAssignments(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	users := NewDBUserRepository(db)
+	roles := NewDBRoleRepository(db)
+
+	user := createTestUser("cascade")
+	err := users.Create(ctx, user)
+	assert.NoError(t, err)
+	err = roles.CreateRole(ctx, &Role{Name: "viewer"})
+	assert.NoError(t, err)
+	err = roles.AssignRole(ctx, user.ID, "viewer")
+	assert.NoError(t, err)
+
+	err = users.Delete(ctx, user.ID)
+	assert.NoError(t, err)
+
+	var count int64
+	db.Model(&UserRole{}).Count(&count)
+	assert.Zero(t, count)
+}
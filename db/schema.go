@@ -6,10 +6,47 @@ import (
 
 // User struct definition
 type User struct {
-	ID        uint   `gorm:"primaryKey"`
-	Email     string `gorm:"unique;not null"`
-	Username  string `gorm:"unique;not null"`
-	Name      string
+	ID       uint   `gorm:"primaryKey"`
+	Email    string `gorm:"unique;not null"`
+	Username string `gorm:"unique;not null"`
+	Name     string
+	// ExternalID is the Taronja Gateway user ID of provisioned users, nil for local users
+	ExternalID *string `gorm:"uniqueIndex:idx_users_external_id"`
+	// Provider is the login provider the user was provisioned from
+	Provider  string `gorm:"not null;default:''"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+// Role is a named set of permissions that can be assigned to users.
+// Gateway admins have every permission without any local role.
+type Role struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"unique;not null"`
+	Description string
+	Permissions []RolePermission `gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// RolePermission grants a permission, e.g. "users:write", to a role
+type RolePermission struct {
+	RoleID     uint   `gorm:"primaryKey"`
+	Permission string `gorm:"primaryKey"`
+}
+
+// UserRole assigns a role to a user
+type UserRole struct {
+	UserID    uint `gorm:"primaryKey"`
+	RoleID    uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+// PermissionNames returns the permissions granted by the role
+func (r *Role) PermissionNames() []string {
+	names := make([]string, 0, len(r.Permissions))
+	for _, permission := range r.Permissions {
+		names = append(names, permission.Permission)
+	}
+	return names
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracerName is the instrumentation scope of the query spans
+const tracerName = "github.com/jmaister/gots-template/db"
+
+// spanKey stores the span of a query in the gorm.DB instance running it
+const spanKey = "tracing:span"
+
+// tracingPlugin creates a client span for every query run with the context of a traced request,
+// as a child of the request span. Queries without a span in their context are not traced.
+type tracingPlugin struct{}
+
+func (tracingPlugin) Name() string {
+	return "tracing"
+}
+
+func (p tracingPlugin) Initialize(db *gorm.DB) error {
+	system := dbSystem(db.Dialector.Name())
+	before := func(db *gorm.DB) { p.before(db, system) }
+
+	callbacks := db.Callback()
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("tracing:before_create", before),
+		callbacks.Create().After("gorm:create").Register("tracing:after_create", p.after),
+		callbacks.Query().Before("gorm:query").Register("tracing:before_query", before),
+		callbacks.Query().After("gorm:query").Register("tracing:after_query", p.after),
+		callbacks.Update().Before("gorm:update").Register("tracing:before_update", before),
+		callbacks.Update().After("gorm:update").Register("tracing:after_update", p.after),
+		callbacks.Delete().Before("gorm:delete").Register("tracing:before_delete", before),
+		callbacks.Delete().After("gorm:delete").Register("tracing:after_delete", p.after),
+		callbacks.Row().Before("gorm:row").Register("tracing:before_row", before),
+		callbacks.Row().After("gorm:row").Register("tracing:after_row", p.after),
+		callbacks.Raw().Before("gorm:raw").Register("tracing:before_raw", before),
+		callbacks.Raw().After("gorm:raw").Register("tracing:after_raw", p.after),
+	)
+}
+
+func (tracingPlugin) before(db *gorm.DB, system attribute.KeyValue) {
+	ctx := db.Statement.Context
+	parent := trace.SpanFromContext(ctx)
+	if !parent.SpanContext().IsValid() {
+		return
+	}
+
+	// The span is named once the SQL is built
+	ctx, span := parent.TracerProvider().Tracer(tracerName).Start(ctx, "query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(system),
+	)
+	db.Statement.Context = ctx
+	db.InstanceSet(spanKey, span)
+}
+
+func (tracingPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	// The SQL has placeholders, the values of the query are never recorded
+	query := db.Statement.SQL.String()
+	operation := "query"
+	fields := strings.Fields(query)
+	if len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	name := operation
+	if db.Statement.Table != "" {
+		name += " " + db.Statement.Table
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+	span.SetName(name)
+	span.SetAttributes(
+		semconv.DBOperationName(operation),
+		semconv.DBQueryText(query),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
+
+// dbSystem returns the db.system.name of a GORM dialector
+func dbSystem(dialector string) attribute.KeyValue {
+	switch dialector {
+	case "postgres":
+		return semconv.DBSystemNamePostgreSQL
+	case "mysql":
+		return semconv.DBSystemNameMySQL
+	case "sqlite":
+		return semconv.DBSystemNameSQLite
+	}
+	return semconv.DBSystemNameKey.String(dialector)
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanAttribute returns the value of an attribute of a recorded span
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingPlugin(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	repo := NewDBUserRepository(setupTestDB(t))
+
+	t.Run("Untraced", func(t *testing.T) {
+		_, err := repo.GetByID(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Empty(t, recorder.Ended(), "Queries outside a traced request are not traced")
+	})
+
+	t.Run("Traced", func(t *testing.T) {
+		ctx, parent := provider.Tracer("test").Start(context.Background(), "GetUser")
+		user := createTestUser("traced")
+		err := repo.Create(ctx, user)
+		assert.NoError(t, err)
+		_, err = repo.GetByID(ctx, user.ID+1)
+		assert.ErrorIs(t, err, ErrNotFound)
+		err = repo.Create(ctx, createTestUser("traced"))
+		assert.Error(t, err, "Duplicate user")
+		parent.End()
+
+		spans := recorder.Ended()
+		assert.Len(t, spans, 4)
+		insert, query, failed := spans[0], spans[1], spans[2]
+		for _, span := range spans[:3] {
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), span.Name())
+			assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+			assert.Equal(t, "sqlite", spanAttribute(span, "db.system.name").AsString())
+			assert.Equal(t, "users", spanAttribute(span, "db.collection.name").AsString())
+		}
+
+		assert.Equal(t, "INSERT users", insert.Name())
+		assert.Equal(t, int64(1), spanAttribute(insert, "db.rows_affected").AsInt64())
+		assert.NotContains(t, spanAttribute(insert, "db.query.text").AsString(), user.Email, "Values are not recorded")
+		assert.Equal(t, codes.Unset, insert.Status().Code)
+
+		assert.Equal(t, "SELECT users", query.Name())
+		assert.Contains(t, spanAttribute(query, "db.query.text").AsString(), "FROM")
+		assert.Equal(t, codes.Unset, query.Status().Code, "Not found is not an error")
+
+		assert.Equal(t, "INSERT users", failed.Name())
+		assert.Equal(t, codes.Error, failed.Status().Code)
+		assert.Len(t, failed.Events(), 1, "The error is recorded")
+	})
+}
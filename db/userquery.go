@@ -0,0 +1,206 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultUserQueryLimit is the page size used when UserQuery.Limit is zero
+	DefaultUserQueryLimit = 20
+	// MaxUserQueryLimit is the largest page size accepted
+	MaxUserQueryLimit = 100
+)
+
+// ErrInvalidUserQuery is returned when a UserQuery cannot be executed. It matches ErrValidation.
+var ErrInvalidUserQuery = fmt.Errorf("%w: invalid user query", ErrValidation)
+
+// UserSortField is a column users can be sorted by
+type UserSortField string
+
+const (
+	UserSortByID        UserSortField = "id"
+	UserSortByEmail     UserSortField = "email"
+	UserSortByUsername  UserSortField = "username"
+	UserSortByName      UserSortField = "name"
+	UserSortByCreatedAt UserSortField = "created_at"
+)
+
+// SortDirection is the direction of a sort
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+// UserQuery filters, sorts and paginates a user listing.
+// Pagination uses either Offset or Cursor (keyset), not both.
+type UserQuery struct {
+	// Email, Username and Name match case-insensitive substrings
+	Email    string
+	Username string
+	Name     string
+	// CreatedFrom is inclusive, CreatedTo is exclusive
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+
+	// SortBy defaults to id, ties are always broken by id
+	SortBy        UserSortField
+	SortDirection SortDirection
+
+	// Limit defaults to DefaultUserQueryLimit and is capped at MaxUserQueryLimit
+	Limit  int
+	Offset int
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+}
+
+// UserPage is a page of users
+type UserPage struct {
+	Users []*User
+	// Total is the number of users matching the filters, across all pages
+	Total int64
+	// NextCursor fetches the following page, empty on the last page. Offset pages have none,
+	// since offset and cursor pagination are not combined.
+	NextCursor string
+}
+
+// userCursor is the position after the last user of a page, encoded in UserPage.NextCursor
+type userCursor struct {
+	SortBy        UserSortField `json:"s"`
+	SortDirection SortDirection `json:"d"`
+	Value         string        `json:"v"`
+	ID            uint          `json:"id"`
+}
+
+// normalize validates the query and fills in defaults
+func (q UserQuery) normalize() (UserQuery, *userCursor, error) {
+	if q.SortBy == "" {
+		q.SortBy = UserSortByID
+	}
+	switch q.SortBy {
+	case UserSortByID, UserSortByEmail, UserSortByUsername, UserSortByName, UserSortByCreatedAt:
+	default:
+		return q, nil, fmt.Errorf("%w: unknown sort field %q", ErrInvalidUserQuery, q.SortBy)
+	}
+
+	if q.SortDirection == "" {
+		q.SortDirection = SortAsc
+	}
+	if q.SortDirection != SortAsc && q.SortDirection != SortDesc {
+		return q, nil, fmt.Errorf("%w: sort direction must be asc or desc", ErrInvalidUserQuery)
+	}
+
+	if q.Limit < 0 || q.Offset < 0 {
+		return q, nil, fmt.Errorf("%w: limit and offset must not be negative", ErrInvalidUserQuery)
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultUserQueryLimit
+	}
+	if q.Limit > MaxUserQueryLimit {
+		q.Limit = MaxUserQueryLimit
+	}
+
+	if q.Cursor == "" {
+		return q, nil, nil
+	}
+	if q.Offset > 0 {
+		return q, nil, fmt.Errorf("%w: use either offset or cursor", ErrInvalidUserQuery)
+	}
+
+	cursor, err := decodeUserCursor(q.Cursor)
+	if err != nil {
+		return q, nil, err
+	}
+	if cursor.SortBy != q.SortBy || cursor.SortDirection != q.SortDirection {
+		return q, nil, fmt.Errorf("%w: cursor was created for a different sort", ErrInvalidUserQuery)
+	}
+	return q, cursor, nil
+}
+
+// sortValue returns the value of the sort field of a user, as stored in cursors
+func sortValue(user *User, field UserSortField) string {
+	switch field {
+	case UserSortByEmail:
+		return user.Email
+	case UserSortByUsername:
+		return user.Username
+	case UserSortByName:
+		return user.Name
+	case UserSortByCreatedAt:
+		return user.CreatedAt.Format(time.RFC3339Nano)
+	default:
+		return strconv.FormatUint(uint64(user.ID), 10)
+	}
+}
+
+// cursorValue converts a cursor value back to the type of its column
+func (c *userCursor) cursorValue() (interface{}, error) {
+	switch c.SortBy {
+	case UserSortByCreatedAt:
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidUserQuery)
+		}
+		return t, nil
+	case UserSortByID:
+		return c.ID, nil
+	default:
+		return c.Value, nil
+	}
+}
+
+// newUserCursor builds the cursor pointing after the given user
+func newUserCursor(q UserQuery, last *User) string {
+	cursor := userCursor{
+		SortBy:        q.SortBy,
+		SortDirection: q.SortDirection,
+		Value:         sortValue(last, q.SortBy),
+		ID:            last.ID,
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeUserCursor(encoded string) (*userCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidUserQuery)
+	}
+
+	var cursor userCursor
+	err = json.Unmarshal(data, &cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidUserQuery)
+	}
+	return &cursor, nil
+}
+
+// newUserPage builds a page from up to Limit+1 users, the extra one only signals a next page
+func newUserPage(query UserQuery, users []*User, total int64) *UserPage {
+	page := &UserPage{
+		Users: users,
+		Total: total,
+	}
+	if len(users) > query.Limit {
+		page.Users = users[:query.Limit]
+		if query.Offset == 0 {
+			page.NextCursor = newUserCursor(query, page.Users[len(page.Users)-1])
+		}
+	}
+	if page.Users == nil {
+		page.Users = []*User{}
+	}
+	return page
+}
+
+// escapeLike escapes LIKE wildcards using '!' as escape character, which works on every dialect
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return "%" + replacer.Replace(strings.ToLower(value)) + "%"
+}
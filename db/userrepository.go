@@ -1,12 +1,20 @@
 package db
 
-// UserRepository interface for abstracting user database operations
+import "context"
+
+// UserRepository interface for abstracting user database operations.
+// Every method takes the request context, so cancellation and deadlines reach the database.
 type UserRepository interface {
-	GetByID(id uint) (*User, error)
-	GetByEmail(email string) (*User, error)
-	GetByUsername(username string) (*User, error)
-	Create(user *User) error
-	Update(user *User) error
-	Delete(id uint) error
-	GetAll() ([]*User, error)
+	GetByID(ctx context.Context, id uint) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	// GetByExternalID finds a user provisioned from the gateway by its gateway user ID
+	GetByExternalID(ctx context.Context, externalID string) (*User, error)
+	Create(ctx context.Context, user *User) error
+	Update(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id uint) error
+	GetAll(ctx context.Context) ([]*User, error)
+	// List returns a filtered, sorted page of users and the total number of matches.
+	// Text fields sort byte by byte, so upper case letters come before lower case ones on every database.
+	List(ctx context.Context, query UserQuery) (*UserPage, error)
 }
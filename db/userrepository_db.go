@@ -1,7 +1,7 @@
 package db
 
 import (
-	"errors"
+	"context"
 
 	"gorm.io/gorm"
 )
@@ -19,62 +19,182 @@ func NewDBUserRepository(db *gorm.DB) *UserRepositoryDB {
 }
 
 // GetByID finds a user by ID
-func (r *UserRepositoryDB) GetByID(id uint) (*User, error) {
+func (r *UserRepositoryDB) GetByID(ctx context.Context, id uint) (*User, error) {
 	var user User
-	result := r.db.First(&user, id)
+	result := r.db.WithContext(ctx).First(&user, id)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, translateUserError(result.Error)
 	}
 	return &user, nil
 }
 
 // GetByEmail finds a user by email
-func (r *UserRepositoryDB) GetByEmail(email string) (*User, error) {
+func (r *UserRepositoryDB) GetByEmail(ctx context.Context, email string) (*User, error) {
 	var user User
-	result := r.db.First(&user, "email = ?", email)
+	result := r.db.WithContext(ctx).First(&user, "email = ?", email)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, translateUserError(result.Error)
 	}
 	return &user, nil
 }
 
 // GetByUsername finds a user by username
-func (r *UserRepositoryDB) GetByUsername(username string) (*User, error) {
+func (r *UserRepositoryDB) GetByUsername(ctx context.Context, username string) (*User, error) {
 	var user User
-	result := r.db.First(&user, "username = ?", username)
+	result := r.db.WithContext(ctx).First(&user, "username = ?", username)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, translateUserError(result.Error)
+	}
+	return &user, nil
+}
+
+// GetByExternalID finds a user by its gateway user ID
+func (r *UserRepositoryDB) GetByExternalID(ctx context.Context, externalID string) (*User, error) {
+	var user User
+	result := r.db.WithContext(ctx).First(&user, "external_id = ?", externalID)
+	if result.Error != nil {
+		return nil, translateUserError(result.Error)
 	}
 	return &user, nil
 }
 
 // Create adds a new user to the repository
-func (r *UserRepositoryDB) Create(user *User) error {
-	result := r.db.Create(user)
-	return result.Error
+func (r *UserRepositoryDB) Create(ctx context.Context, user *User) error {
+	err := validateUser(user)
+	if err != nil {
+		return err
+	}
+
+	result := r.db.WithContext(ctx).Create(user)
+	return translateUserError(result.Error)
 }
 
 // Update updates an existing user in the repository
-func (r *UserRepositoryDB) Update(user *User) error {
-	result := r.db.Save(user)
-	return result.Error
+func (r *UserRepositoryDB) Update(ctx context.Context, user *User) error {
+	err := validateUser(user)
+	if err != nil {
+		return err
+	}
+	if user.ID == 0 {
+		return userNotFound()
+	}
+
+	// Unlike Save, Updates never inserts a missing user
+	result := r.db.WithContext(ctx).Model(user).Select("*").Updates(user)
+	if result.Error != nil {
+		return translateUserError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return userNotFound()
+	}
+	return nil
 }
 
 // Delete removes a user from the repository
-func (r *UserRepositoryDB) Delete(id uint) error {
-	result := r.db.Delete(&User{}, id)
+func (r *UserRepositoryDB) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&User{}, id)
+	if result.Error != nil {
+		return translateUserError(result.Error)
+	}
 	if result.RowsAffected == 0 {
-		return errors.New("user not found")
+		return userNotFound()
 	}
-	return result.Error
+	return nil
 }
 
 // GetAll retrieves all users from the database
-func (r *UserRepositoryDB) GetAll() ([]*User, error) {
+func (r *UserRepositoryDB) GetAll(ctx context.Context) ([]*User, error) {
 	var users []*User
-	result := r.db.Find(&users)
+	result := r.db.WithContext(ctx).Find(&users)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return users, nil
 }
+
+// sortColumn returns the expression a query sorts by. Text columns are compared byte by byte like
+// strings.Compare in UserRepositoryMemory, whatever the collation of the database: the default
+// collation of MySQL ignores case and PostgreSQL follows the locale.
+func (r *UserRepositoryDB) sortColumn(field UserSortField) string {
+	column := string(field)
+	if field != UserSortByEmail && field != UserSortByUsername && field != UserSortByName {
+		return column
+	}
+	switch r.db.Dialector.Name() {
+	case "postgres":
+		return column + ` COLLATE "C"`
+	case "mysql":
+		return "CAST(" + column + " AS BINARY)"
+	}
+	// SQLite compares with the BINARY collation by default
+	return column
+}
+
+// List returns a filtered, sorted page of users and the total number of matches
+func (r *UserRepositoryDB) List(ctx context.Context, query UserQuery) (*UserPage, error) {
+	query, cursor, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	// Each call starts a new statement, so the count and the page do not share clauses
+	filtered := func() *gorm.DB {
+		tx := r.db.WithContext(ctx).Model(&User{})
+		if query.Email != "" {
+			tx = tx.Where("LOWER(email) LIKE ? ESCAPE '!'", escapeLike(query.Email))
+		}
+		if query.Username != "" {
+			tx = tx.Where("LOWER(username) LIKE ? ESCAPE '!'", escapeLike(query.Username))
+		}
+		if query.Name != "" {
+			tx = tx.Where("LOWER(name) LIKE ? ESCAPE '!'", escapeLike(query.Name))
+		}
+		if query.CreatedFrom != nil {
+			tx = tx.Where("created_at >= ?", *query.CreatedFrom)
+		}
+		if query.CreatedTo != nil {
+			tx = tx.Where("created_at < ?", *query.CreatedTo)
+		}
+		return tx
+	}
+
+	var total int64
+	result := filtered().Count(&total)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	column := r.sortColumn(query.SortBy)
+	direction := string(query.SortDirection)
+	operator := ">"
+	if query.SortDirection == SortDesc {
+		operator = "<"
+	}
+
+	tx := filtered()
+	if cursor != nil {
+		// Keyset pagination: continue after the last row of the previous page, ties broken by id
+		if query.SortBy == UserSortByID {
+			tx = tx.Where("id "+operator+" ?", cursor.ID)
+		} else {
+			value, err := cursor.cursorValue()
+			if err != nil {
+				return nil, err
+			}
+			tx = tx.Where("("+column+" "+operator+" ? OR ("+column+" = ? AND id "+operator+" ?))", value, value, cursor.ID)
+		}
+	}
+	if query.SortBy != UserSortByID {
+		tx = tx.Order(column + " " + direction)
+	}
+	tx = tx.Order("id " + direction)
+
+	// Fetch one extra row to know whether there is a next page
+	var users []*User
+	result = tx.Offset(query.Offset).Limit(query.Limit + 1).Find(&users)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return newUserPage(query, users, total), nil
+}
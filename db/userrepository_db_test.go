@@ -1,25 +1,42 @@
 package db
 
 import (
+	"context"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/jmaister/gots-template/config"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-// setupTestDB creates a new in-memory test database for each test
+// setupTestDB creates a migrated test database for each test.
+// It uses SQLite in memory by default. Set TEST_DATABASE_URL (e.g. postgres://... or mysql://...)
+// to run the repository suite against a local instance of another dialect.
 func setupTestDB(t *testing.T) *gorm.DB {
-	// Use a unique database name for each test to ensure isolation
-	dbName := "file::memory:?cache=shared&_" + t.Name()
-	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		// Use a named in-memory database for each test to ensure isolation,
+		// with foreign keys enforced like the default pragmas do in production
+		databaseURL = "sqlite://file:" + t.Name() + "?mode=memory&cache=shared&_pragma=foreign_keys(1)"
+	}
+
+	db, err := Open(config.DatabaseConfig{URL: databaseURL}, discardLogger)
 	assert.NoError(t, err)
 
-	// Migrate the schema
-	err = db.AutoMigrate(&User{})
+	// Migrate the schema with the same migrations used in production
+	err = runMigrations(db, discardLogger)
 	assert.NoError(t, err)
 
+	if os.Getenv("TEST_DATABASE_URL") != "" {
+		// External databases persist between tests, start from empty tables
+		err = db.Exec("DELETE FROM users").Error
+		assert.NoError(t, err)
+		err = db.Exec("DELETE FROM roles").Error
+		assert.NoError(t, err)
+	}
+
 	return db
 }
 
@@ -44,14 +61,14 @@ func TestGetByID(t *testing.T) {
 	assert.NoError(t, result.Error)
 
 	// Test finding by ID
-	foundUser, err := repo.GetByID(testUser.ID)
+	foundUser, err := repo.GetByID(context.Background(), testUser.ID)
 	assert.NoError(t, err)
 	assert.NotNil(t, foundUser)
 	assert.Equal(t, testUser.ID, foundUser.ID)
 	assert.Equal(t, testUser.Email, foundUser.Email)
 
 	// Test not found case
-	foundUser, err = repo.GetByID(999)
+	foundUser, err = repo.GetByID(context.Background(), 999)
 	assert.Error(t, err)
 	assert.Nil(t, foundUser)
 }
@@ -66,14 +83,14 @@ func TestGetByEmail(t *testing.T) {
 	assert.NoError(t, result.Error)
 
 	// Test finding by email
-	foundUser, err := repo.GetByEmail(testUser.Email)
+	foundUser, err := repo.GetByEmail(context.Background(), testUser.Email)
 	assert.NoError(t, err)
 	assert.NotNil(t, foundUser)
 	assert.Equal(t, testUser.Email, foundUser.Email)
 	assert.Equal(t, testUser.Username, foundUser.Username)
 
 	// Test not found case
-	foundUser, err = repo.GetByEmail("nonexistent@example.com")
+	foundUser, err = repo.GetByEmail(context.Background(), "nonexistent@example.com")
 	assert.Error(t, err)
 	assert.Nil(t, foundUser)
 }
@@ -88,14 +105,14 @@ func TestGetByUsername(t *testing.T) {
 	assert.NoError(t, result.Error)
 
 	// Test finding by username
-	foundUser, err := repo.GetByUsername(testUser.Username)
+	foundUser, err := repo.GetByUsername(context.Background(), testUser.Username)
 	assert.NoError(t, err)
 	assert.NotNil(t, foundUser)
 	assert.Equal(t, testUser.Username, foundUser.Username)
 	assert.Equal(t, testUser.Email, foundUser.Email)
 
 	// Test not found case
-	foundUser, err = repo.GetByUsername("nonexistent")
+	foundUser, err = repo.GetByUsername(context.Background(), "nonexistent")
 	assert.Error(t, err)
 	assert.Nil(t, foundUser)
 }
@@ -106,7 +123,7 @@ func TestCreate(t *testing.T) {
 
 	// Create a test user
 	testUser := createTestUser("create-test")
-	err := repo.Create(testUser)
+	err := repo.Create(context.Background(), testUser)
 	assert.NoError(t, err)
 	assert.NotZero(t, testUser.ID) // Verify ID is generated
 
@@ -130,7 +147,7 @@ func TestUpdate(t *testing.T) {
 	// Update user
 	testUser.Name = "Updated Name"
 	testUser.Email = "updated@example.com"
-	err := repo.Update(testUser)
+	err := repo.Update(context.Background(), testUser)
 	assert.NoError(t, err)
 
 	// Verify changes persisted
@@ -151,7 +168,7 @@ func TestDelete(t *testing.T) {
 	assert.NoError(t, result.Error)
 
 	// Delete user
-	err := repo.Delete(testUser.ID)
+	err := repo.Delete(context.Background(), testUser.ID)
 	assert.NoError(t, err)
 
 	// Verify user is deleted
@@ -160,9 +177,8 @@ func TestDelete(t *testing.T) {
 	assert.Error(t, result.Error) // Should not find the user
 
 	// Test deleting non-existent user
-	err = repo.Delete(999)
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "user not found")
+	err = repo.Delete(context.Background(), 999)
+	assert.ErrorIs(t, err, ErrNotFound)
 }
 
 func TestGetAll(t *testing.T) {
@@ -181,7 +197,7 @@ func TestGetAll(t *testing.T) {
 	}
 
 	// Get all users
-	users, err := repo.GetAll()
+	users, err := repo.GetAll(context.Background())
 	assert.NoError(t, err)
 	assert.Len(t, users, expectedCount)
 }
@@ -192,18 +208,84 @@ func TestUniqueConstraints(t *testing.T) {
 
 	// Create initial test user
 	testUser := createTestUser("unique-test")
-	err := repo.Create(testUser)
+	err := repo.Create(context.Background(), testUser)
 	assert.NoError(t, err)
 
 	// Try to create user with same email
 	duplicateEmailUser := createTestUser("unique-email-test")
 	duplicateEmailUser.Email = testUser.Email
-	err = repo.Create(duplicateEmailUser)
-	assert.Error(t, err, "Should fail due to unique email constraint")
+	err = repo.Create(context.Background(), duplicateEmailUser)
+	assert.ErrorIs(t, err, ErrConflict, "Should fail due to unique email constraint")
 
 	// Try to create user with same username
 	duplicateUsernameUser := createTestUser("unique-username-test")
 	duplicateUsernameUser.Username = testUser.Username
-	err = repo.Create(duplicateUsernameUser)
-	assert.Error(t, err, "Should fail due to unique username constraint")
+	err = repo.Create(context.Background(), duplicateUsernameUser)
+	assert.ErrorIs(t, err, ErrConflict, "Should fail due to unique username constraint")
+}
+
+// repositories returns an empty repository for every implementation
+func repositories(t *testing.T) map[string]UserRepository {
+	return map[string]UserRepository{
+		"DB":     NewDBUserRepository(setupTestDB(t)),
+		"Memory": NewMemoryUserRepository(),
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			user := createTestUser("cancelled-" + name)
+			err := repo.Create(context.Background(), user)
+			assert.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err = repo.GetByID(ctx, user.ID)
+			assert.ErrorIs(t, err, context.Canceled)
+			_, err = repo.List(ctx, UserQuery{})
+			assert.ErrorIs(t, err, context.Canceled)
+			err = repo.Delete(ctx, user.ID)
+			assert.ErrorIs(t, err, context.Canceled)
+
+			// Nothing was deleted
+			_, err = repo.GetByID(context.Background(), user.ID)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGetByExternalID(t *testing.T) {
+	for name, repo := range repositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			externalID := "gateway-" + name
+
+			user := createTestUser("external-" + name)
+			user.ExternalID = &externalID
+			user.Provider = "google"
+			err := repo.Create(ctx, user)
+			assert.NoError(t, err)
+
+			// Local users without an external ID do not collide with each other
+			err = repo.Create(ctx, createTestUser("local-1-"+name))
+			assert.NoError(t, err)
+			err = repo.Create(ctx, createTestUser("local-2-"+name))
+			assert.NoError(t, err)
+
+			found, err := repo.GetByExternalID(ctx, externalID)
+			assert.NoError(t, err)
+			assert.Equal(t, user.ID, found.ID)
+			assert.Equal(t, "google", found.Provider)
+
+			_, err = repo.GetByExternalID(ctx, "unknown")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			duplicate := createTestUser("external-dup-" + name)
+			duplicate.ExternalID = &externalID
+			err = repo.Create(ctx, duplicate)
+			assert.ErrorIs(t, err, ErrConflict)
+		})
+	}
 }
@@ -0,0 +1,233 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// UserOperation names a write operation on users
+type UserOperation string
+
+const (
+	UserOperationCreate UserOperation = "create"
+	UserOperationUpdate UserOperation = "update"
+	UserOperationDelete UserOperation = "delete"
+)
+
+// Hook types run around user write operations.
+// Before-hooks can modify the new value or veto the operation by returning an error.
+// After-hooks run once the operation succeeded and receive the old and new values.
+// Hooks receive the context of the operation, to be used for any I/O they do.
+type (
+	BeforeCreateUserHook func(ctx context.Context, user *User) error
+	AfterCreateUserHook  func(ctx context.Context, user *User)
+	BeforeUpdateUserHook func(ctx context.Context, old *User, new *User) error
+	AfterUpdateUserHook  func(ctx context.Context, old *User, new *User)
+	BeforeDeleteUserHook func(ctx context.Context, old *User) error
+	AfterDeleteUserHook  func(ctx context.Context, old *User)
+)
+
+// UserHookVetoError is returned when a before-hook rejects an operation. Err is the error the hook
+// returned when it did not call VetoUser.
+type UserHookVetoError struct {
+	Operation UserOperation
+	Reason    string
+	Err       error
+}
+
+// VetoUser returns the error a before-hook uses to reject an operation with a reason
+func VetoUser(reason string) error {
+	return &UserHookVetoError{Reason: reason}
+}
+
+func (e *UserHookVetoError) Error() string {
+	return fmt.Sprintf("user %s vetoed: %s", e.Operation, e.Reason)
+}
+
+// PublicDetail is the message shown to API clients: the reason given to VetoUser, or a generic
+// message when the hook returned another error, whose text may hold internal details
+func (e *UserHookVetoError) PublicDetail() string {
+	if e.Err != nil {
+		return fmt.Sprintf("user %s was rejected", e.Operation)
+	}
+	return e.Error()
+}
+
+func (e *UserHookVetoError) Unwrap() error {
+	return e.Err
+}
+
+// UserRepositoryHooked decorates any UserRepository with lifecycle hooks,
+// so every implementation fires them identically
+type UserRepositoryHooked struct {
+	UserRepository
+
+	mu           sync.RWMutex
+	beforeCreate []BeforeCreateUserHook
+	afterCreate  []AfterCreateUserHook
+	beforeUpdate []BeforeUpdateUserHook
+	afterUpdate  []AfterUpdateUserHook
+	beforeDelete []BeforeDeleteUserHook
+	afterDelete  []AfterDeleteUserHook
+}
+
+// NewHookedUserRepository wraps a user repository to run registered hooks on Create, Update and Delete
+func NewHookedUserRepository(inner UserRepository) *UserRepositoryHooked {
+	return &UserRepositoryHooked{
+		UserRepository: inner,
+	}
+}
+
+// OnBeforeCreate registers a hook run before a user is created
+func (r *UserRepositoryHooked) OnBeforeCreate(hook BeforeCreateUserHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.beforeCreate = append(r.beforeCreate, hook)
+}
+
+// OnAfterCreate registers a hook run after a user is created
+func (r *UserRepositoryHooked) OnAfterCreate(hook AfterCreateUserHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.afterCreate = append(r.afterCreate, hook)
+}
+
+// OnBeforeUpdate registers a hook run before a user is updated
+func (r *UserRepositoryHooked) OnBeforeUpdate(hook BeforeUpdateUserHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.beforeUpdate = append(r.beforeUpdate, hook)
+}
+
+// OnAfterUpdate registers a hook run after a user is updated
+func (r *UserRepositoryHooked) OnAfterUpdate(hook AfterUpdateUserHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.afterUpdate = append(r.afterUpdate, hook)
+}
+
+// OnBeforeDelete registers a hook run before a user is deleted
+func (r *UserRepositoryHooked) OnBeforeDelete(hook BeforeDeleteUserHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.beforeDelete = append(r.beforeDelete, hook)
+}
+
+// OnAfterDelete registers a hook run after a user is deleted
+func (r *UserRepositoryHooked) OnAfterDelete(hook AfterDeleteUserHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.afterDelete = append(r.afterDelete, hook)
+}
+
+// Create runs the before-create hooks, creates the user and runs the after-create hooks
+func (r *UserRepositoryHooked) Create(ctx context.Context, user *User) error {
+	r.mu.RLock()
+	before, after := r.beforeCreate, r.afterCreate
+	r.mu.RUnlock()
+
+	for _, hook := range before {
+		err := hook(ctx, user)
+		if err != nil {
+			return vetoError(UserOperationCreate, err)
+		}
+	}
+
+	err := r.UserRepository.Create(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range after {
+		hook(ctx, user)
+	}
+	return nil
+}
+
+// Update runs the before-update hooks, updates the user and runs the after-update hooks
+func (r *UserRepositoryHooked) Update(ctx context.Context, user *User) error {
+	r.mu.RLock()
+	before, after := r.beforeUpdate, r.afterUpdate
+	r.mu.RUnlock()
+
+	old, err := r.snapshot(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range before {
+		err := hook(ctx, old, user)
+		if err != nil {
+			return vetoError(UserOperationUpdate, err)
+		}
+	}
+
+	err = r.UserRepository.Update(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range after {
+		hook(ctx, old, user)
+	}
+	return nil
+}
+
+// Delete runs the before-delete hooks, deletes the user and runs the after-delete hooks
+func (r *UserRepositoryHooked) Delete(ctx context.Context, id uint) error {
+	r.mu.RLock()
+	before, after := r.beforeDelete, r.afterDelete
+	r.mu.RUnlock()
+
+	old, err := r.snapshot(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range before {
+		err := hook(ctx, old)
+		if err != nil {
+			return vetoError(UserOperationDelete, err)
+		}
+	}
+
+	err = r.UserRepository.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range after {
+		hook(ctx, old)
+	}
+	return nil
+}
+
+// snapshot returns a copy of the stored user, so hooks see the value before the change
+func (r *UserRepositoryHooked) snapshot(ctx context.Context, id uint) (*User, error) {
+	stored, err := r.UserRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	old := *stored
+	return &old, nil
+}
+
+// vetoError wraps the error returned by a before-hook into a *UserHookVetoError
+func vetoError(operation UserOperation, err error) error {
+	veto, ok := err.(*UserHookVetoError)
+	if ok {
+		// Copy so hooks can return a shared error value
+		named := *veto
+		named.Operation = operation
+		return &named
+	}
+	return &UserHookVetoError{
+		Operation: operation,
+		Reason:    err.Error(),
+		Err:       err,
+	}
+}
+
+// Ensure UserRepositoryHooked implements UserRepository
+var _ UserRepository = (*UserRepositoryHooked)(nil)
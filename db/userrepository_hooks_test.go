@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hookedRepositories returns a hooked repository for every implementation,
+// so each test checks that hooks fire identically on all of them
+func hookedRepositories(t *testing.T) map[string]*UserRepositoryHooked {
+	return map[string]*UserRepositoryHooked{
+		"DB":     NewHookedUserRepository(NewDBUserRepository(setupTestDB(t))),
+		"Memory": NewHookedUserRepository(NewMemoryUserRepository()),
+	}
+}
+
+func TestHooksCreate(t *testing.T) {
+	for name, repo := range hookedRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			var calls []string
+			repo.OnBeforeCreate(func(ctx context.Context, user *User) error {
+				calls = append(calls, "before")
+				// Before-hooks can enrich the user
+				user.Name = "Enriched " + user.Name
+				return nil
+			})
+			repo.OnAfterCreate(func(ctx context.Context, user *User) {
+				calls = append(calls, "after")
+				assert.NotZero(t, user.ID, "After-create should see the generated ID")
+			})
+
+			user := createTestUser("hooks-create-" + name)
+			err := repo.Create(context.Background(), user)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"before", "after"}, calls)
+
+			stored, err := repo.GetByID(context.Background(), user.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, "Enriched Test User hooks-create-"+name, stored.Name)
+		})
+	}
+}
+
+func TestHooksVeto(t *testing.T) {
+	for name, repo := range hookedRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			errReserved := errors.New("reserved username")
+			repo.OnBeforeCreate(func(ctx context.Context, user *User) error {
+				if user.Username == "admin" {
+					return VetoUser("username is reserved")
+				}
+				return nil
+			})
+			repo.OnBeforeDelete(func(ctx context.Context, old *User) error {
+				return errReserved
+			})
+			afterCalled := false
+			repo.OnAfterCreate(func(ctx context.Context, user *User) {
+				afterCalled = true
+			})
+
+			// Vetoed with VetoUser
+			user := createTestUser("hooks-veto-" + name)
+			user.Username = "admin"
+			err := repo.Create(context.Background(), user)
+
+			var veto *UserHookVetoError
+			assert.True(t, errors.As(err, &veto))
+			assert.Equal(t, UserOperationCreate, veto.Operation)
+			assert.Equal(t, "username is reserved", veto.Reason)
+			assert.False(t, afterCalled, "After-hooks should not run when vetoed")
+			assert.Zero(t, user.ID, "Vetoed user should not be stored")
+
+			// Vetoed with a plain error, which is wrapped
+			user = createTestUser("hooks-veto-delete-" + name)
+			assert.NoError(t, repo.Create(context.Background(), user))
+			err = repo.Delete(context.Background(), user.ID)
+			assert.True(t, errors.As(err, &veto))
+			assert.Equal(t, UserOperationDelete, veto.Operation)
+			assert.ErrorIs(t, err, errReserved)
+
+			_, err = repo.GetByID(context.Background(), user.ID)
+			assert.NoError(t, err, "Vetoed delete should keep the user")
+		})
+	}
+}
+
+func TestHooksUpdateReceivesOldAndNew(t *testing.T) {
+	for name, repo := range hookedRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			user := createTestUser("hooks-update-" + name)
+			assert.NoError(t, repo.Create(context.Background(), user))
+
+			var beforeOld, afterOld, afterNew User
+			repo.OnBeforeUpdate(func(ctx context.Context, old *User, new *User) error {
+				beforeOld = *old
+				return nil
+			})
+			repo.OnAfterUpdate(func(ctx context.Context, old *User, new *User) {
+				afterOld = *old
+				afterNew = *new
+			})
+
+			// Modify a user obtained from the repository, as handlers do
+			loaded, err := repo.GetByID(context.Background(), user.ID)
+			assert.NoError(t, err)
+			loaded.Name = "New Name"
+			err = repo.Update(context.Background(), loaded)
+			assert.NoError(t, err)
+
+			assert.Equal(t, "Test User hooks-update-"+name, beforeOld.Name)
+			assert.Equal(t, "Test User hooks-update-"+name, afterOld.Name)
+			assert.Equal(t, "New Name", afterNew.Name)
+		})
+	}
+}
+
+func TestHooksDeleteReceivesOld(t *testing.T) {
+	for name, repo := range hookedRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			user := createTestUser("hooks-delete-" + name)
+			assert.NoError(t, repo.Create(context.Background(), user))
+
+			var deleted *User
+			repo.OnAfterDelete(func(ctx context.Context, old *User) {
+				deleted = old
+			})
+
+			err := repo.Delete(context.Background(), user.ID)
+			assert.NoError(t, err)
+			assert.NotNil(t, deleted)
+			assert.Equal(t, user.Email, deleted.Email)
+
+			// Deleting a missing user fails before any hook runs
+			deleted = nil
+			err = repo.Delete(context.Background(), user.ID)
+			assert.Error(t, err)
+			assert.Nil(t, deleted)
+		})
+	}
+}
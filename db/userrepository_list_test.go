@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// listRepositories returns every implementation seeded with the same users,
+// so each test checks that listing behaves identically on all of them
+func listRepositories(t *testing.T) map[string]UserRepository {
+	repos := map[string]UserRepository{
+		"DB":     NewDBUserRepository(setupTestDB(t)),
+		"Memory": NewMemoryUserRepository(),
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed := []struct {
+		username string
+		name     string
+		email    string
+	}{
+		{"carol", "Carol Smith", "carol@example.com"},
+		{"alice", "Alice Smith", "alice@example.com"},
+		{"dave", "Dave Jones", "dave@example.org"},
+		{"bob", "Bob Jones", "bob@example.com"},
+		{"erin", "Erin 100% Smith", "erin@example.org"},
+	}
+	for _, repo := range repos {
+		for i, s := range seed {
+			user := &User{
+				Username:  s.username,
+				Name:      s.name,
+				Email:     s.email,
+				CreatedAt: base.Add(time.Duration(i) * 24 * time.Hour),
+			}
+			err := repo.Create(context.Background(), user)
+			assert.NoError(t, err)
+		}
+	}
+	return repos
+}
+
+func usernames(page *UserPage) []string {
+	names := make([]string, 0, len(page.Users))
+	for _, user := range page.Users {
+		names = append(names, user.Username)
+	}
+	return names
+}
+
+func TestListDefaults(t *testing.T) {
+	for name, repo := range listRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			page, err := repo.List(context.Background(), UserQuery{})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(5), page.Total)
+			assert.Equal(t, []string{"carol", "alice", "dave", "bob", "erin"}, usernames(page), "Should sort by id")
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    UserQuery
+		expected []string
+	}{
+		{"Email", UserQuery{Email: "EXAMPLE.ORG"}, []string{"dave", "erin"}},
+		{"Username", UserQuery{Username: "o"}, []string{"carol", "bob"}},
+		{"Name", UserQuery{Name: "smith"}, []string{"carol", "alice", "erin"}},
+		{"Wildcards are literal", UserQuery{Name: "100%"}, []string{"erin"}},
+		{"Underscore is literal", UserQuery{Name: "_"}, []string{}},
+		{"Created range", UserQuery{CreatedFrom: &from, CreatedTo: &to}, []string{"alice", "dave"}},
+		{"Combined", UserQuery{Name: "jones", Email: ".com"}, []string{"bob"}},
+	}
+
+	for name, repo := range listRepositories(t) {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				page, err := repo.List(context.Background(), tt.query)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, usernames(page))
+				assert.Equal(t, int64(len(tt.expected)), page.Total)
+			})
+		}
+	}
+}
+
+func TestListSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    UserQuery
+		expected []string
+	}{
+		{"Username asc", UserQuery{SortBy: UserSortByUsername}, []string{"alice", "bob", "carol", "dave", "erin"}},
+		{"Email desc", UserQuery{SortBy: UserSortByEmail, SortDirection: SortDesc}, []string{"erin", "dave", "carol", "bob", "alice"}},
+		{"Created at desc", UserQuery{SortBy: UserSortByCreatedAt, SortDirection: SortDesc}, []string{"erin", "bob", "dave", "alice", "carol"}},
+		{"Id desc", UserQuery{SortDirection: SortDesc}, []string{"erin", "bob", "dave", "alice", "carol"}},
+	}
+
+	for name, repo := range listRepositories(t) {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				page, err := repo.List(context.Background(), tt.query)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, usernames(page))
+			})
+		}
+	}
+}
+
+func TestListSortIsCaseSensitive(t *testing.T) {
+	repos := map[string]UserRepository{
+		"DB":     NewDBUserRepository(setupTestDB(t)),
+		"Memory": NewMemoryUserRepository(),
+	}
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			for _, username := range []string{"beth", "Adam", "carl", "Bill"} {
+				err := repo.Create(context.Background(), &User{Username: username, Email: username + "@example.com"})
+				assert.NoError(t, err)
+			}
+
+			page, err := repo.List(context.Background(), UserQuery{SortBy: UserSortByUsername, Limit: 2})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"Adam", "Bill"}, usernames(page))
+
+			page, err = repo.List(context.Background(), UserQuery{SortBy: UserSortByUsername, Cursor: page.NextCursor})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"beth", "carl"}, usernames(page), "The cursor follows the same order")
+		})
+	}
+}
+
+func TestListOffset(t *testing.T) {
+	for name, repo := range listRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			page, err := repo.List(context.Background(), UserQuery{SortBy: UserSortByUsername, Limit: 2, Offset: 2})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"carol", "dave"}, usernames(page))
+			assert.Equal(t, int64(5), page.Total)
+			assert.Empty(t, page.NextCursor, "Offset pages continue with an offset, not a cursor")
+
+			page, err = repo.List(context.Background(), UserQuery{Offset: 10})
+			assert.NoError(t, err)
+			assert.Empty(t, page.Users)
+			assert.Equal(t, int64(5), page.Total)
+		})
+	}
+}
+
+func TestListCursor(t *testing.T) {
+	sorts := []UserQuery{
+		{SortBy: UserSortByID},
+		{SortBy: UserSortByName, SortDirection: SortDesc},
+		{SortBy: UserSortByCreatedAt},
+	}
+
+	for name, repo := range listRepositories(t) {
+		for _, sort := range sorts {
+			t.Run(name+"/"+string(sort.SortBy), func(t *testing.T) {
+				all, err := repo.List(context.Background(), sort)
+				assert.NoError(t, err)
+
+				// Walk every page and check they add up to the unpaginated listing
+				var walked []string
+				query := sort
+				query.Limit = 2
+				for i := 0; i < 10; i++ {
+					page, err := repo.List(context.Background(), query)
+					assert.NoError(t, err)
+					assert.Equal(t, int64(5), page.Total)
+					walked = append(walked, usernames(page)...)
+					if page.NextCursor == "" {
+						break
+					}
+					query.Cursor = page.NextCursor
+				}
+				assert.Equal(t, usernames(all), walked)
+			})
+		}
+	}
+}
+
+func TestListInvalidQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query UserQuery
+	}{
+		{"Unknown sort field", UserQuery{SortBy: "password"}},
+		{"Unknown direction", UserQuery{SortDirection: "sideways"}},
+		{"Negative limit", UserQuery{Limit: -1}},
+		{"Malformed cursor", UserQuery{Cursor: "not a cursor"}},
+		{"Offset with cursor", UserQuery{Offset: 1, Cursor: newUserCursor(UserQuery{SortBy: UserSortByID, SortDirection: SortAsc}, &User{ID: 1})}},
+		{"Cursor of another sort", UserQuery{SortBy: UserSortByEmail, Cursor: newUserCursor(UserQuery{SortBy: UserSortByID, SortDirection: SortAsc}, &User{ID: 1})}},
+	}
+
+	for name, repo := range listRepositories(t) {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				_, err := repo.List(context.Background(), tt.query)
+				assert.ErrorIs(t, err, ErrInvalidUserQuery)
+			})
+		}
+	}
+}
+
+func TestListLimitIsCapped(t *testing.T) {
+	query, _, err := UserQuery{Limit: 1000}.normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, MaxUserQueryLimit, query.Limit)
+
+	query, _, err = UserQuery{}.normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultUserQueryLimit, query.Limit)
+}
@@ -1,11 +1,16 @@
 package db
 
 import (
-	"errors"
+	"context"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
-// UserRepositoryMemory implements UserRepository using in-memory storage
+// UserRepositoryMemory implements UserRepository using in-memory storage.
+// Users are stored and returned as copies, like rows read from a database,
+// and a cancelled context fails the call like it would fail a query.
 type UserRepositoryMemory struct {
 	users map[uint]*User
 	mu    sync.RWMutex
@@ -20,86 +25,304 @@ func NewMemoryUserRepository() *UserRepositoryMemory {
 }
 
 // GetByID finds a user by ID
-func (r *UserRepositoryMemory) GetByID(id uint) (*User, error) {
+func (r *UserRepositoryMemory) GetByID(ctx context.Context, id uint) (*User, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	user, exists := r.users[id]
 	if !exists {
-		return nil, errors.New("user not found")
+		return nil, userNotFound()
 	}
-	return user, nil
+	return copyUser(user), nil
 }
 
 // GetByEmail finds a user by email
-func (r *UserRepositoryMemory) GetByEmail(email string) (*User, error) {
+func (r *UserRepositoryMemory) GetByEmail(ctx context.Context, email string) (*User, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, user := range r.users {
 		if user.Email == email {
-			return user, nil
+			return copyUser(user), nil
 		}
 	}
-	return nil, errors.New("user not found")
+	return nil, userNotFound()
 }
 
 // GetByUsername finds a user by username
-func (r *UserRepositoryMemory) GetByUsername(username string) (*User, error) {
+func (r *UserRepositoryMemory) GetByUsername(ctx context.Context, username string) (*User, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, user := range r.users {
 		if user.Username == username {
-			return user, nil
+			return copyUser(user), nil
 		}
 	}
-	return nil, errors.New("user not found")
+	return nil, userNotFound()
+}
+
+// GetByExternalID finds a user by its gateway user ID
+func (r *UserRepositoryMemory) GetByExternalID(ctx context.Context, externalID string) (*User, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.ExternalID != nil && *user.ExternalID == externalID {
+			return copyUser(user), nil
+		}
+	}
+	return nil, userNotFound()
 }
 
 // Create adds a new user to the repository
-func (r *UserRepositoryMemory) Create(user *User) error {
+func (r *UserRepositoryMemory) Create(ctx context.Context, user *User) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	err = validateUser(user)
+	if err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	err = r.checkUnique(user)
+	if err != nil {
+		return err
+	}
+
+	// Set timestamps like GORM does
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	user.UpdatedAt = now
+
 	r.id++
 	user.ID = r.id
-	r.users[user.ID] = user
+	r.users[user.ID] = copyUser(user)
 	return nil
 }
 
 // Update updates an existing user in the repository
-func (r *UserRepositoryMemory) Update(user *User) error {
+func (r *UserRepositoryMemory) Update(ctx context.Context, user *User) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	err = validateUser(user)
+	if err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, exists := r.users[user.ID]; !exists {
-		return errors.New("user not found")
+		return userNotFound()
+	}
+	err = r.checkUnique(user)
+	if err != nil {
+		return err
 	}
-	r.users[user.ID] = user
+	user.UpdatedAt = time.Now()
+	r.users[user.ID] = copyUser(user)
 	return nil
 }
 
 // Delete removes a user from the repository
-func (r *UserRepositoryMemory) Delete(id uint) error {
+func (r *UserRepositoryMemory) Delete(ctx context.Context, id uint) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, exists := r.users[id]; !exists {
-		return errors.New("user not found")
+		return userNotFound()
 	}
 	delete(r.users, id)
 	return nil
 }
 
 // GetAll retrieves all users
-func (r *UserRepositoryMemory) GetAll() ([]*User, error) {
+func (r *UserRepositoryMemory) GetAll(ctx context.Context) ([]*User, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	users := make([]*User, 0, len(r.users))
 	for _, user := range r.users {
-		users = append(users, user)
+		users = append(users, copyUser(user))
 	}
 	return users, nil
 }
+
+// List returns a filtered, sorted page of users and the total number of matches
+func (r *UserRepositoryMemory) List(ctx context.Context, query UserQuery) (*UserPage, error) {
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	query, cursor, err := query.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	matches := make([]*User, 0, len(r.users))
+	for _, user := range r.users {
+		if matchesUserQuery(user, query) {
+			matches = append(matches, copyUser(user))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		return compareUsers(matches[i], matches[j], query) < 0
+	})
+	total := int64(len(matches))
+
+	if cursor != nil {
+		// Keyset pagination: skip users up to and including the cursor position
+		last := &User{ID: cursor.ID}
+		switch query.SortBy {
+		case UserSortByEmail:
+			last.Email = cursor.Value
+		case UserSortByUsername:
+			last.Username = cursor.Value
+		case UserSortByName:
+			last.Name = cursor.Value
+		case UserSortByCreatedAt:
+			value, err := cursor.cursorValue()
+			if err != nil {
+				return nil, err
+			}
+			last.CreatedAt = value.(time.Time)
+		}
+		start := sort.Search(len(matches), func(i int) bool {
+			return compareUsers(matches[i], last, query) > 0
+		})
+		matches = matches[start:]
+	}
+
+	if query.Offset >= len(matches) {
+		matches = nil
+	} else {
+		matches = matches[query.Offset:]
+	}
+	if len(matches) > query.Limit+1 {
+		matches = matches[:query.Limit+1]
+	}
+
+	return newUserPage(query, matches, total), nil
+}
+
+// checkUnique enforces the unique email and username constraints of the database schema.
+// The caller must hold the write lock.
+func (r *UserRepositoryMemory) checkUnique(user *User) error {
+	for id, other := range r.users {
+		if id == user.ID {
+			continue
+		}
+		if other.Email == user.Email {
+			return userConflict("email")
+		}
+		if other.Username == user.Username {
+			return userConflict("username")
+		}
+		if other.ExternalID != nil && user.ExternalID != nil && *other.ExternalID == *user.ExternalID {
+			return userConflict("external_id")
+		}
+	}
+	return nil
+}
+
+// matchesUserQuery reports whether a user passes the filters of a query
+func matchesUserQuery(user *User, query UserQuery) bool {
+	if query.Email != "" && !strings.Contains(strings.ToLower(user.Email), strings.ToLower(query.Email)) {
+		return false
+	}
+	if query.Username != "" && !strings.Contains(strings.ToLower(user.Username), strings.ToLower(query.Username)) {
+		return false
+	}
+	if query.Name != "" && !strings.Contains(strings.ToLower(user.Name), strings.ToLower(query.Name)) {
+		return false
+	}
+	if query.CreatedFrom != nil && user.CreatedAt.Before(*query.CreatedFrom) {
+		return false
+	}
+	if query.CreatedTo != nil && !user.CreatedAt.Before(*query.CreatedTo) {
+		return false
+	}
+	return true
+}
+
+// compareUsers orders users by the sort field of a query, ties broken by id
+func compareUsers(a *User, b *User, query UserQuery) int {
+	result := 0
+	switch query.SortBy {
+	case UserSortByEmail:
+		result = strings.Compare(a.Email, b.Email)
+	case UserSortByUsername:
+		result = strings.Compare(a.Username, b.Username)
+	case UserSortByName:
+		result = strings.Compare(a.Name, b.Name)
+	case UserSortByCreatedAt:
+		result = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if result == 0 {
+		switch {
+		case a.ID < b.ID:
+			result = -1
+		case a.ID > b.ID:
+			result = 1
+		}
+	}
+	if query.SortDirection == SortDesc {
+		result = -result
+	}
+	return result
+}
+
+// copyUser returns a copy so callers cannot change stored users without calling Update
+func copyUser(user *User) *User {
+	copied := *user
+	if user.ExternalID != nil {
+		externalID := *user.ExternalID
+		copied.ExternalID = &externalID
+	}
+	return &copied
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/jmaister/gots-template/api"
+	"github.com/jmaister/gots-template/health"
+	"github.com/jmaister/gots-template/session"
 )
 
 // HealthCheck implements the HealthCheck operation for the api.StrictServerInterface.
@@ -13,8 +15,7 @@ func (s *StrictApiServer) HealthCheck(ctx context.Context, request api.HealthChe
 	uptime := time.Since(s.StartTime)
 	uptimeStr := uptime.String()
 
-	// You can customize the version or make it dynamic
-	version := "1.0.0"
+	version := s.BuildInfo.Version
 
 	response := api.HealthCheck200JSONResponse{
 		Status:    "ok",
@@ -24,3 +25,52 @@ func (s *StrictApiServer) HealthCheck(ctx context.Context, request api.HealthChe
 	}
 	return response, nil
 }
+
+// HealthLive implements the HealthLive operation, answering while the process serves requests
+func (s *StrictApiServer) HealthLive(ctx context.Context, request api.HealthLiveRequestObject) (api.HealthLiveResponseObject, error) {
+	uptime := time.Since(s.StartTime).String()
+	return api.HealthLive200JSONResponse{
+		Status:    "ok",
+		Timestamp: time.Now(),
+		Uptime:    &uptime,
+	}, nil
+}
+
+// HealthReady implements the HealthReady operation, running the registered health checks
+func (s *StrictApiServer) HealthReady(ctx context.Context, request api.HealthReadyRequestObject) (api.HealthReadyResponseObject, error) {
+	report := s.HealthChecks.Check(ctx)
+
+	response := api.ReadinessResponse{
+		Status:    api.ReadinessResponseStatus(report.Status),
+		Timestamp: time.Now(),
+		Checks:    make([]api.HealthCheckResult, 0, len(report.Checks)),
+	}
+	for _, result := range report.Checks {
+		check := api.HealthCheckResult{
+			Name:      result.Name,
+			Status:    api.HealthCheckResultStatus(result.Status),
+			Critical:  result.Critical,
+			LatencyMs: float64(result.Latency.Microseconds()) / 1000,
+			CheckedAt: result.CheckedAt,
+			Cached:    result.Cached,
+		}
+		if result.Status == health.StatusFail {
+			// The details may name hosts, URLs or paths, they are only logged
+			publicError := api.HealthCheckResultErrorCheckFailed
+			if result.TimedOut {
+				publicError = api.HealthCheckResultErrorCheckTimedOut
+			}
+			check.Error = &publicError
+			// Cached results were logged when they ran
+			if !result.Cached {
+				session.Logger(ctx).WarnContext(ctx, "Health check failed", "check", result.Name, "critical", result.Critical, "error", result.Error)
+			}
+		}
+		response.Checks = append(response.Checks, check)
+	}
+
+	if report.Status == health.StatusFail {
+		return api.HealthReady503JSONResponse(response), nil
+	}
+	return api.HealthReady200JSONResponse(response), nil
+}
@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"log/slog"
 	"testing"
 	"time"
 
 	"github.com/jmaister/gots-template/api"
+	"github.com/jmaister/gots-template/buildinfo"
+	"github.com/jmaister/gots-template/db"
+	"github.com/jmaister/gots-template/health"
+	"github.com/jmaister/gots-template/session"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestHealthCheck(t *testing.T) {
 	// Create a new StrictApiServer instance
-	s := NewStrictApiServer()
+	s := NewStrictApiServer(db.NewMemoryUserRepository(), nil, nil, buildinfo.Info{Version: "1.4.0"})
 
 	t.Run("SuccessfulHealthCheck", func(t *testing.T) {
 		// Setup: Create a health check request
@@ -38,9 +45,9 @@ func TestHealthCheck(t *testing.T) {
 		// Assert: Status should be "ok"
 		assert.Equal(t, "ok", healthResp.Status)
 
-		// Assert: Version should be set
+		// Assert: Version should be the one of the build
 		assert.NotNil(t, healthResp.Version)
-		assert.Equal(t, "1.0.0", *healthResp.Version)
+		assert.Equal(t, "1.4.0", *healthResp.Version)
 
 		// Assert: Uptime should be set
 		assert.NotNil(t, healthResp.Uptime)
@@ -62,3 +69,66 @@ func TestHealthCheck(t *testing.T) {
 	})
 
 }
+
+func TestHealthLive(t *testing.T) {
+	s := NewStrictApiServer(db.NewMemoryUserRepository(), nil, nil, buildinfo.Info{})
+
+	resp, err := s.HealthLive(context.Background(), api.HealthLiveRequestObject{})
+	assert.NoError(t, err)
+	live, ok := resp.(api.HealthLive200JSONResponse)
+	assert.True(t, ok, "Response should be HealthLive200JSONResponse")
+	assert.Equal(t, "ok", live.Status)
+	assert.NotNil(t, live.Uptime)
+}
+
+func TestHealthReady(t *testing.T) {
+	healthChecks := health.NewRegistry(time.Second, 0)
+	healthChecks.Register("database", true, health.CheckerFunc(func(ctx context.Context) error { return nil }))
+	gatewayErr := errors.New("connection refused")
+	healthChecks.Register("gateway", false, health.CheckerFunc(func(ctx context.Context) error { return gatewayErr }))
+	var schemaErr error
+	healthChecks.Register("schema", true, health.CheckerFunc(func(ctx context.Context) error { return schemaErr }))
+	s := NewStrictApiServer(db.NewMemoryUserRepository(), nil, healthChecks, buildinfo.Info{})
+
+	var logs bytes.Buffer
+	ctx := session.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil)))
+
+	t.Run("Degraded", func(t *testing.T) {
+		resp, err := s.HealthReady(ctx, api.HealthReadyRequestObject{})
+		assert.NoError(t, err)
+		ready, ok := resp.(api.HealthReady200JSONResponse)
+		assert.True(t, ok, "A non critical failure is still ready")
+		assert.Equal(t, api.ReadinessResponseStatusDegraded, ready.Status)
+		assert.Len(t, ready.Checks, 3)
+
+		database, gateway := ready.Checks[0], ready.Checks[1]
+		assert.Equal(t, "database", database.Name)
+		assert.Equal(t, api.HealthCheckResultStatusOk, database.Status)
+		assert.True(t, database.Critical)
+		assert.Nil(t, database.Error)
+		assert.GreaterOrEqual(t, database.LatencyMs, float64(0))
+		assert.Equal(t, api.HealthCheckResultStatusFail, gateway.Status)
+		assert.Equal(t, api.HealthCheckResultErrorCheckFailed, *gateway.Error, "The details are not public")
+		assert.Contains(t, logs.String(), "connection refused", "The details are logged")
+	})
+
+	t.Run("NotReady", func(t *testing.T) {
+		schemaErr = db.ErrPendingMigration
+		resp, err := s.HealthReady(ctx, api.HealthReadyRequestObject{})
+		assert.NoError(t, err)
+		notReady, ok := resp.(api.HealthReady503JSONResponse)
+		assert.True(t, ok, "Response should be HealthReady503JSONResponse")
+		assert.Equal(t, api.ReadinessResponseStatusFail, notReady.Status)
+		assert.Equal(t, api.HealthCheckResultErrorCheckFailed, *notReady.Checks[2].Error)
+		assert.Contains(t, logs.String(), "pending migration")
+	})
+
+	t.Run("NoChecks", func(t *testing.T) {
+		resp, err := NewStrictApiServer(db.NewMemoryUserRepository(), nil, nil, buildinfo.Info{}).HealthReady(context.Background(), api.HealthReadyRequestObject{})
+		assert.NoError(t, err)
+		ready, ok := resp.(api.HealthReady200JSONResponse)
+		assert.True(t, ok)
+		assert.Equal(t, api.ReadinessResponseStatusOk, ready.Status)
+		assert.Empty(t, ready.Checks)
+	})
+}
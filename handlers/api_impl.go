@@ -4,6 +4,10 @@ import (
 	"time"
 
 	"github.com/jmaister/gots-template/api"
+	"github.com/jmaister/gots-template/buildinfo"
+	"github.com/jmaister/gots-template/db"
+	"github.com/jmaister/gots-template/health"
+	"github.com/jmaister/gots-template/services"
 )
 
 // --- Strict API Server Implementation ---
@@ -12,12 +16,24 @@ import (
 type StrictApiServer struct {
 	// StartTime records when the server was started for uptime calculation
 	StartTime time.Time
+	// UserRepository stores the users served by the /api/users endpoints
+	UserRepository db.UserRepository
+	// MeService fetches the profile of the current user from Taronja Gateway
+	MeService services.MeServiceInterface
+	// HealthChecks are run by the readiness probe, nil reports ready without checks
+	HealthChecks *health.Registry
+	// BuildInfo describes the running binary, reported by /api/health and /api/version
+	BuildInfo buildinfo.Info
 }
 
 // NewStrictApiServer creates a new StrictApiServer.
-func NewStrictApiServer() *StrictApiServer {
+func NewStrictApiServer(userRepository db.UserRepository, meService services.MeServiceInterface, healthChecks *health.Registry, info buildinfo.Info) *StrictApiServer {
 	return &StrictApiServer{
-		StartTime: time.Now(),
+		StartTime:      time.Now(),
+		UserRepository: userRepository,
+		MeService:      meService,
+		HealthChecks:   healthChecks,
+		BuildInfo:      info,
 	}
 }
 
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jmaister/gots-template/api"
+	"github.com/jmaister/gots-template/session"
+)
+
+// GetMe implements the GetMe operation for the api.StrictServerInterface.
+// The session forwarded by the gateway identifies the user, the gateway profile completes it.
+func (s *StrictApiServer) GetMe(ctx context.Context, request api.GetMeRequestObject) (api.GetMeResponseObject, error) {
+	reqCtx, err := session.GetRequestContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !reqCtx.IsAuthenticated {
+		return unauthenticatedMe(ctx), nil
+	}
+
+	userData, err := reqCtx.GetUserData()
+	if err != nil {
+		if errors.Is(err, session.ErrUnauthenticated) {
+			return unauthenticatedMe(ctx), nil
+		}
+		return nil, err
+	}
+
+	profile, err := s.MeService.GetMe(ctx)
+	if err != nil {
+		if errors.Is(err, session.ErrUnauthenticated) {
+			return unauthenticatedMe(ctx), nil
+		}
+		return nil, err
+	}
+
+	me := api.GetMe200JSONResponse{
+		Authenticated: true,
+		UserId:        reqCtx.UserID,
+		Username:      userData.Username,
+		IsAdmin:       userData.IsAdmin,
+	}
+	if userData.Email != "" {
+		me.Email = &userData.Email
+	}
+	if userData.Provider != "" {
+		me.Provider = &userData.Provider
+	}
+	if !userData.ValidUntil.IsZero() {
+		me.SessionValidUntil = &userData.ValidUntil
+	}
+
+	// The gateway profile is more recent than the session snapshot
+	if profile.JSON200 != nil {
+		user := profile.JSON200
+		if user.Username != nil && *user.Username != "" {
+			me.Username = *user.Username
+		}
+		if user.Email != nil && *user.Email != "" {
+			email := string(*user.Email)
+			me.Email = &email
+		}
+		if user.IsAdmin != nil {
+			me.IsAdmin = *user.IsAdmin
+		}
+		if user.Provider != nil && *user.Provider != "" {
+			me.Provider = user.Provider
+		}
+		me.Name = user.Name
+		me.GivenName = user.GivenName
+		me.FamilyName = user.FamilyName
+		me.Picture = user.Picture
+		me.Timestamp = user.Timestamp
+	}
+	return me, nil
+}
+
+// unauthenticatedMe is the 401 problem returned when there is no authenticated session
+func unauthenticatedMe(ctx context.Context) api.GetMe401ApplicationProblemPlusJSONResponse {
+	problem := NewRequestProblem(ctx, http.StatusUnauthorized, "Authentication is required")
+	return api.GetMe401ApplicationProblemPlusJSONResponse{
+		ProblemApplicationProblemPlusJSONResponse: api.ProblemApplicationProblemPlusJSONResponse(problem),
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jmaister/gots-template/api"
+	"github.com/jmaister/gots-template/buildinfo"
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/db"
+	"github.com/jmaister/gots-template/services"
+	"github.com/jmaister/gots-template/session"
+	client "github.com/jmaister/taronja-gateway-clients/go"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newMeServer creates an API server whose MeService talks to a test gateway answering /_/me
+func newMeServer(t *testing.T, gateway http.HandlerFunc) *StrictApiServer {
+	gatewayServer := httptest.NewServer(gateway)
+	t.Cleanup(gatewayServer.Close)
+
+	taronjaClient, err := client.NewClientWithResponses(gatewayServer.URL + "/_/")
+	assert.NoError(t, err)
+	meService := services.NewMeService(taronjaClient, config.GatewayConfig{}, nil)
+	return NewStrictApiServer(db.NewMemoryUserRepository(), meService, nil, buildinfo.Info{})
+}
+
+// requestContext returns the context a strict handler receives for a request
+func requestContext(t *testing.T, r *http.Request) context.Context {
+	var captured context.Context
+	next := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		captured = ctx
+		return nil, nil
+	}
+	_, err := session.StrictInjectHTTPRequestMiddleware(next, "GetMe")(context.Background(), httptest.NewRecorder(), r, nil)
+	assert.NoError(t, err)
+	return captured
+}
+
+func authenticatedRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	r.Header.Set(session.HeaderRequestID, "req-me")
+	r.Header.Set(session.HeaderUserId, "user-1")
+	r.Header.Set(session.HeaderUserData, `{"Token":"token-1","UserID":"user-1","Username":"jane","Email":"old@example.com","Provider":"google","ValidUntil":"2030-01-01T00:00:00Z"}`)
+	return r
+}
+
+func TestGetMe(t *testing.T) {
+	t.Run("Authenticated", func(t *testing.T) {
+		var forwardedToken, forwardedRequestID string
+		s := newMeServer(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/_/me", r.URL.Path)
+			forwardedRequestID = r.Header.Get(session.HeaderRequestID)
+			cookie, err := r.Cookie(session.CookieSession)
+			if err == nil {
+				forwardedToken = cookie.Value
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"authenticated":true,"username":"jane","email":"jane@example.com","name":"Jane Doe","givenName":"Jane","isAdmin":true}`))
+		})
+
+		resp, err := s.GetMe(requestContext(t, authenticatedRequest()), api.GetMeRequestObject{})
+		assert.NoError(t, err)
+		me, ok := resp.(api.GetMe200JSONResponse)
+		assert.True(t, ok, "Response should be GetMe200JSONResponse")
+
+		assert.Equal(t, "token-1", forwardedToken, "The session token should be forwarded to the gateway")
+		assert.Equal(t, "req-me", forwardedRequestID, "The request ID should be forwarded to the gateway")
+		assert.True(t, me.Authenticated)
+		assert.Equal(t, "user-1", me.UserId)
+		assert.Equal(t, "jane", me.Username)
+		assert.Equal(t, "jane@example.com", *me.Email, "The gateway profile wins over the session")
+		assert.Equal(t, "Jane Doe", *me.Name)
+		assert.Equal(t, "google", *me.Provider)
+		assert.True(t, me.IsAdmin)
+		assert.Equal(t, 2030, me.SessionValidUntil.Year())
+	})
+
+	t.Run("NoSession", func(t *testing.T) {
+		s := newMeServer(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Error("The gateway should not be called without a session")
+		})
+
+		r := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+		r.Header.Set(session.HeaderRequestID, "req-anonymous")
+		resp, err := s.GetMe(requestContext(t, r), api.GetMeRequestObject{})
+		assert.NoError(t, err)
+		problem, ok := resp.(api.GetMe401ApplicationProblemPlusJSONResponse)
+		assert.True(t, ok, "Response should be GetMe401ApplicationProblemPlusJSONResponse")
+		assert.Equal(t, http.StatusUnauthorized, problem.Status)
+		assert.Equal(t, "req-anonymous", *problem.RequestId)
+		assert.Equal(t, "/api/me", *problem.Instance)
+	})
+
+	t.Run("SessionRejectedByGateway", func(t *testing.T) {
+		s := newMeServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":401,"message":"session expired"}`))
+		})
+
+		resp, err := s.GetMe(requestContext(t, authenticatedRequest()), api.GetMeRequestObject{})
+		assert.NoError(t, err)
+		assert.IsType(t, api.GetMe401ApplicationProblemPlusJSONResponse{}, resp)
+	})
+
+	t.Run("GatewayFailure", func(t *testing.T) {
+		s := newMeServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+
+		_, err := s.GetMe(requestContext(t, authenticatedRequest()), api.GetMeRequestObject{})
+		assert.ErrorIs(t, err, services.ErrUpstream)
+	})
+
+	t.Run("TraceContext", func(t *testing.T) {
+		var forwardedTraceparent string
+		s := newMeServer(t, func(w http.ResponseWriter, r *http.Request) {
+			forwardedTraceparent = r.Header.Get("traceparent")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"authenticated":true,"username":"jane"}`))
+		})
+
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		ctx, parent := provider.Tracer("test").Start(requestContext(t, authenticatedRequest()), "GetMe")
+		_, err := s.GetMe(ctx, api.GetMeRequestObject{})
+		assert.NoError(t, err)
+		parent.End()
+
+		spans := recorder.Ended()
+		assert.Len(t, spans, 2)
+		gatewaySpan := spans[0]
+		assert.Equal(t, "GetCurrentUser", gatewaySpan.Name())
+		assert.Equal(t, trace.SpanKindClient, gatewaySpan.SpanKind())
+		assert.Equal(t, parent.SpanContext().SpanID(), gatewaySpan.Parent().SpanID())
+		assert.Equal(t, "00-"+gatewaySpan.SpanContext().TraceID().String()+"-"+gatewaySpan.SpanContext().SpanID().String()+"-01",
+			forwardedTraceparent, "The gateway call continues the trace")
+	})
+}
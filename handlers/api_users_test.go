@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmaister/gots-template/api"
+	"github.com/jmaister/gots-template/buildinfo"
+	"github.com/jmaister/gots-template/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// createAPIUser creates a user through the API and returns it
+func createAPIUser(t *testing.T, s *StrictApiServer, username string, name string) api.User {
+	resp, err := s.CreateUser(context.Background(), api.CreateUserRequestObject{
+		Body: &api.UserCreate{
+			Email:    username + "@example.com",
+			Username: username,
+			Name:     ptr(name),
+		},
+	})
+	assert.NoError(t, err)
+	created, ok := resp.(api.CreateUser201JSONResponse)
+	assert.True(t, ok, "Response should be CreateUser201JSONResponse")
+	return api.User(created)
+}
+
+func TestCreateUser(t *testing.T) {
+	s := NewStrictApiServer(db.NewMemoryUserRepository(), nil, nil, buildinfo.Info{})
+
+	t.Run("Created", func(t *testing.T) {
+		user := createAPIUser(t, s, "jane", "Jane Doe")
+		assert.NotZero(t, user.Id)
+		assert.Equal(t, "jane@example.com", user.Email)
+		assert.Equal(t, "Jane Doe", user.Name)
+		assert.False(t, user.CreatedAt.IsZero())
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		_, err := s.CreateUser(context.Background(), api.CreateUserRequestObject{
+			Body: &api.UserCreate{Email: "jane@example.com", Username: "jane2"},
+		})
+		assert.ErrorIs(t, err, db.ErrConflict)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := s.CreateUser(context.Background(), api.CreateUserRequestObject{
+			Body: &api.UserCreate{Email: "not-an-email", Username: "someone"},
+		})
+		assert.ErrorIs(t, err, db.ErrValidation)
+	})
+}
+
+func TestGetUser(t *testing.T) {
+	s := NewStrictApiServer(db.NewMemoryUserRepository(), nil, nil, buildinfo.Info{})
+	created := createAPIUser(t, s, "jane", "Jane Doe")
+
+	resp, err := s.GetUser(context.Background(), api.GetUserRequestObject{Id: created.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, api.GetUser200JSONResponse(created), resp)
+
+	_, err = s.GetUser(context.Background(), api.GetUserRequestObject{Id: 999})
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
+
+func TestUpdateUser(t *testing.T) {
+	s := NewStrictApiServer(db.NewMemoryUserRepository(), nil, nil, buildinfo.Info{})
+	created := createAPIUser(t, s, "jane", "Jane Doe")
+
+	resp, err := s.UpdateUser(context.Background(), api.UpdateUserRequestObject{
+		Id:   created.Id,
+		Body: &api.UserUpdate{Name: ptr("Jane Smith")},
+	})
+	assert.NoError(t, err)
+	updated, ok := resp.(api.UpdateUser200JSONResponse)
+	assert.True(t, ok, "Response should be UpdateUser200JSONResponse")
+	assert.Equal(t, "Jane Smith", updated.Name)
+	assert.Equal(t, created.Email, updated.Email, "Fields absent from the body should not change")
+	assert.Equal(t, created.Username, updated.Username)
+
+	_, err = s.UpdateUser(context.Background(), api.UpdateUserRequestObject{
+		Id:   created.Id,
+		Body: &api.UserUpdate{Email: ptr("")},
+	})
+	assert.ErrorIs(t, err, db.ErrValidation)
+
+	_, err = s.UpdateUser(context.Background(), api.UpdateUserRequestObject{
+		Id:   999,
+		Body: &api.UserUpdate{Name: ptr("Nobody")},
+	})
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
+
+func TestDeleteUser(t *testing.T) {
+	s := NewStrictApiServer(db.NewMemoryUserRepository(), nil, nil, buildinfo.Info{})
+	created := createAPIUser(t, s, "jane", "Jane Doe")
+
+	resp, err := s.DeleteUser(context.Background(), api.DeleteUserRequestObject{Id: created.Id})
+	assert.NoError(t, err)
+	assert.IsType(t, api.DeleteUser204Response{}, resp)
+
+	_, err = s.DeleteUser(context.Background(), api.DeleteUserRequestObject{Id: created.Id})
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
+
+func TestInvalidUserID(t *testing.T) {
+	// The GORM repositories fail on ids that wrap around, the handlers must not reach them
+	s := NewStrictApiServer(nil, nil, nil, buildinfo.Info{})
+	for _, id := range []int64{0, -1} {
+		_, err := s.GetUser(context.Background(), api.GetUserRequestObject{Id: id})
+		assert.ErrorIs(t, err, db.ErrNotFound)
+		_, err = s.UpdateUser(context.Background(), api.UpdateUserRequestObject{Id: id, Body: &api.UserUpdate{Name: ptr("Nobody")}})
+		assert.ErrorIs(t, err, db.ErrNotFound)
+		_, err = s.DeleteUser(context.Background(), api.DeleteUserRequestObject{Id: id})
+		assert.ErrorIs(t, err, db.ErrNotFound)
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	s := NewStrictApiServer(db.NewMemoryUserRepository(), nil, nil, buildinfo.Info{})
+	createAPIUser(t, s, "carol", "Carol Smith")
+	createAPIUser(t, s, "alice", "Alice Smith")
+	createAPIUser(t, s, "bob", "Bob Jones")
+
+	list := func(params api.ListUsersParams) api.ListUsers200JSONResponse {
+		resp, err := s.ListUsers(context.Background(), api.ListUsersRequestObject{Params: params})
+		assert.NoError(t, err)
+		page, ok := resp.(api.ListUsers200JSONResponse)
+		assert.True(t, ok, "Response should be ListUsers200JSONResponse")
+		return page
+	}
+	usernames := func(page api.ListUsers200JSONResponse) []string {
+		names := []string{}
+		for _, user := range page.Items {
+			names = append(names, user.Username)
+		}
+		return names
+	}
+
+	t.Run("FilterAndSort", func(t *testing.T) {
+		sort := api.ListUsersParamsSortUsername
+		order := api.ListUsersParamsOrderDesc
+		page := list(api.ListUsersParams{Name: ptr("smith"), Sort: &sort, Order: &order})
+		assert.Equal(t, []string{"carol", "alice"}, usernames(page))
+		assert.Equal(t, int64(2), page.Total)
+		assert.Nil(t, page.NextCursor)
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		first := list(api.ListUsersParams{Limit: ptr(2)})
+		assert.Equal(t, []string{"carol", "alice"}, usernames(first))
+		assert.NotNil(t, first.NextCursor)
+
+		second := list(api.ListUsersParams{Limit: ptr(2), Cursor: first.NextCursor})
+		assert.Equal(t, []string{"bob"}, usernames(second))
+		assert.Equal(t, int64(3), second.Total)
+	})
+
+	t.Run("InvalidSort", func(t *testing.T) {
+		sort := api.ListUsersParamsSort("password")
+		_, err := s.ListUsers(context.Background(), api.ListUsersRequestObject{
+			Params: api.ListUsersParams{Sort: &sort},
+		})
+		assert.ErrorIs(t, err, db.ErrValidation)
+	})
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/jmaister/gots-template/api"
+)
+
+// GetVersion implements the GetVersion operation for the api.StrictServerInterface.
+// The authorization policy of the operation requires a session.
+func (s *StrictApiServer) GetVersion(ctx context.Context, request api.GetVersionRequestObject) (api.GetVersionResponseObject, error) {
+	info := s.BuildInfo
+	response := api.GetVersion200JSONResponse{
+		Version:   info.Version,
+		Commit:    info.Commit,
+		Dirty:     info.Dirty,
+		Date:      info.Date,
+		Os:        info.OS,
+		Arch:      info.Arch,
+		GoVersion: info.GoVersion,
+		Deps:      make([]api.BuildModule, 0, len(info.Deps)),
+	}
+	for _, dep := range info.Deps {
+		module := api.BuildModule{Path: dep.Path, Version: dep.Version}
+		if dep.Replace != "" {
+			module.Replace = &dep.Replace
+		}
+		response.Deps = append(response.Deps, module)
+	}
+	return response, nil
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmaister/gots-template/api"
+	"github.com/jmaister/gots-template/buildinfo"
+	"github.com/jmaister/gots-template/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVersion(t *testing.T) {
+	info := buildinfo.Info{
+		Version:   "1.4.0",
+		Commit:    "0123456789abcdef",
+		Dirty:     true,
+		Date:      "2026-01-02T03:04:05Z",
+		OS:        "linux",
+		Arch:      "amd64",
+		GoVersion: "go1.24.2",
+		Deps: []buildinfo.Module{
+			{Path: "gorm.io/gorm", Version: "v1.31.1"},
+			{Path: "github.com/example/fork", Version: "v1.0.0", Replace: "../fork"},
+		},
+	}
+	s := NewStrictApiServer(db.NewMemoryUserRepository(), nil, nil, info)
+
+	resp, err := s.GetVersion(context.Background(), api.GetVersionRequestObject{})
+	assert.NoError(t, err)
+	version, ok := resp.(api.GetVersion200JSONResponse)
+	assert.True(t, ok, "Response should be GetVersion200JSONResponse")
+
+	assert.Equal(t, "1.4.0", version.Version)
+	assert.Equal(t, "0123456789abcdef", version.Commit)
+	assert.True(t, version.Dirty)
+	assert.Equal(t, "2026-01-02T03:04:05Z", version.Date)
+	assert.Equal(t, "linux", version.Os)
+	assert.Equal(t, "amd64", version.Arch)
+	assert.Equal(t, "go1.24.2", version.GoVersion)
+	assert.Len(t, version.Deps, 2)
+	assert.Nil(t, version.Deps[0].Replace)
+	assert.Equal(t, "../fork", *version.Deps[1].Replace)
+}
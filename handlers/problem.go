@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jmaister/gots-template/api"
+	"github.com/jmaister/gots-template/db"
+	"github.com/jmaister/gots-template/services"
+	"github.com/jmaister/gots-template/session"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// StatusClientClosedRequest is the non standard status of requests the client abandoned, so
+// they are told apart from server errors in the logs and metrics
+const StatusClientClosedRequest = 499
+
+// NewProblem creates a problem for a status, titled with its standard text
+func NewProblem(status int, detail string) api.Problem {
+	problem := api.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+	if detail != "" {
+		problem.Detail = &detail
+	}
+	return problem
+}
+
+// NewRequestProblem creates a problem for a typed response of a strict handler,
+// completed with the request path and ID from the session.RequestContext
+func NewRequestProblem(ctx context.Context, status int, detail string) api.Problem {
+	problem := NewProblem(status, detail)
+	reqCtx, err := session.GetRequestContext(ctx)
+	if err != nil {
+		return problem
+	}
+	if reqCtx.RawRequest != nil {
+		instance := reqCtx.RawRequest.URL.Path
+		problem.Instance = &instance
+	}
+	if reqCtx.RequestID != "" {
+		requestID := reqCtx.RequestID
+		problem.RequestId = &requestID
+	}
+	return problem
+}
+
+// ProblemFromError maps a domain error to a problem. Errors that are not part of the
+// domain become a 500 without details, so internal messages never reach the client.
+func ProblemFromError(err error) api.Problem {
+	var veto *db.UserHookVetoError
+	var validation *db.ValidationError
+
+	switch {
+	// Vetoes wrap the error of the hook, whose text must not reach the client
+	case errors.As(err, &veto):
+		return vetoProblem(veto)
+	case errors.Is(err, db.ErrNotFound):
+		return NewProblem(http.StatusNotFound, err.Error())
+	case errors.Is(err, db.ErrConflict):
+		return NewProblem(http.StatusConflict, err.Error())
+	case errors.As(err, &validation):
+		problem := NewProblem(http.StatusUnprocessableEntity, err.Error())
+		problem.Errors = fieldErrors(validation)
+		return problem
+	case errors.Is(err, db.ErrValidation):
+		return NewProblem(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, session.ErrUnauthenticated):
+		return NewProblem(http.StatusUnauthorized, "Authentication is required")
+	case errors.Is(err, session.ErrForbidden):
+		return NewProblem(http.StatusForbidden, "You do not have permission to perform this operation")
+	case errors.Is(err, services.ErrUpstream):
+		return NewProblem(http.StatusBadGateway, "An upstream service failed")
+	case errors.Is(err, context.Canceled):
+		problem := NewProblem(StatusClientClosedRequest, "")
+		problem.Title = "Client Closed Request"
+		return problem
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewProblem(http.StatusServiceUnavailable, "The request took too long")
+	default:
+		return NewProblem(http.StatusInternalServerError, "")
+	}
+}
+
+// vetoProblem maps a hook veto to a problem with its public detail only. The status and the
+// invalid fields of the error the hook returned are kept.
+func vetoProblem(veto *db.UserHookVetoError) api.Problem {
+	status := http.StatusUnprocessableEntity
+	switch {
+	case errors.Is(veto.Err, db.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(veto.Err, db.ErrConflict):
+		status = http.StatusConflict
+	}
+	problem := NewProblem(status, veto.PublicDetail())
+
+	var validation *db.ValidationError
+	if errors.As(veto.Err, &validation) {
+		problem.Errors = fieldErrors(validation)
+	}
+	return problem
+}
+
+// fieldErrors returns the invalid fields of a validation error
+func fieldErrors(validation *db.ValidationError) *[]api.FieldError {
+	fields := make([]api.FieldError, 0, len(validation.Fields))
+	for _, field := range validation.Fields {
+		fields = append(fields, api.FieldError{Field: field.Field, Message: field.Message})
+	}
+	return &fields
+}
+
+// WriteProblem writes a problem as application/problem+json, completed with the request path and ID
+func WriteProblem(w http.ResponseWriter, r *http.Request, problem api.Problem) {
+	if problem.Instance == nil {
+		instance := r.URL.Path
+		problem.Instance = &instance
+	}
+	if problem.RequestId == nil {
+		requestID := requestIDFor(w, r)
+		if requestID != "" {
+			problem.RequestId = &requestID
+		}
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	err := json.NewEncoder(w).Encode(problem)
+	if err != nil {
+		session.Logger(r.Context()).ErrorContext(r.Context(), "Cannot write problem response", "error", err)
+	}
+}
+
+// requestIDFor returns the ID of the request. The error handlers receive the request as it was
+// before the strict middlewares ran, so without RequestIDMiddleware the ID is read back from the
+// response header set by StrictInjectHTTPRequestMiddleware.
+func requestIDFor(w http.ResponseWriter, r *http.Request) string {
+	requestID := session.RequestIDFromContext(r.Context())
+	if requestID != "" {
+		return requestID
+	}
+	return w.Header().Get(session.HeaderRequestID)
+}
+
+// ParameterErrorHandler answers requests with invalid path, query or header parameters with a 400 problem
+func ParameterErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	session.Logger(r.Context()).InfoContext(r.Context(), "Invalid request parameters", "error", err)
+
+	detail := "The request parameters are invalid"
+	var invalidFormat *api.InvalidParamFormatError
+	var required *api.RequiredParamError
+	var requiredHeader *api.RequiredHeaderError
+	var unmarshaling *api.UnmarshalingParamError
+	var tooMany *api.TooManyValuesForParamError
+	switch {
+	case errors.As(err, &invalidFormat):
+		detail = "Invalid format for parameter " + invalidFormat.ParamName
+	case errors.As(err, &required):
+		detail = "Missing required parameter " + required.ParamName
+	case errors.As(err, &requiredHeader):
+		detail = "Missing required header " + requiredHeader.ParamName
+	case errors.As(err, &unmarshaling):
+		detail = "Invalid value for parameter " + unmarshaling.ParamName
+	case errors.As(err, &tooMany):
+		detail = "Too many values for parameter " + tooMany.ParamName
+	}
+	WriteProblem(w, r, NewProblem(http.StatusBadRequest, detail))
+}
+
+// RequestErrorHandler answers requests whose body could not be decoded with a 400 problem.
+// The decoder error is only logged, it can reveal implementation details.
+func RequestErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	session.Logger(r.Context()).InfoContext(r.Context(), "Invalid request body", "error", err)
+	WriteProblem(w, r, NewProblem(http.StatusBadRequest, "The request body could not be decoded"))
+}
+
+// ResponseErrorHandler maps the errors returned by the strict handlers to problems. The errors
+// whose message the problem hides are logged.
+func ResponseErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	problem := ProblemFromError(err)
+	ctx := r.Context()
+	logger := session.Logger(ctx)
+	var veto *db.UserHookVetoError
+	switch {
+	case problem.Status == StatusClientClosedRequest:
+		logger.InfoContext(ctx, "Request canceled by the client", "error", err)
+	case problem.Status == http.StatusServiceUnavailable:
+		logger.WarnContext(ctx, "Request timed out", "error", err)
+	case problem.Status >= http.StatusInternalServerError:
+		logger.ErrorContext(ctx, "Request failed", "error", err)
+	case errors.As(err, &veto) && veto.Err != nil:
+		logger.InfoContext(ctx, "User hook rejected the request", "error", err)
+	}
+	WriteProblem(w, r, problem)
+}
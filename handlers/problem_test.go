@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jmaister/gots-template/api"
+	"github.com/jmaister/gots-template/buildinfo"
+	"github.com/jmaister/gots-template/db"
+	"github.com/jmaister/gots-template/services"
+	"github.com/jmaister/gots-template/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemFromError(t *testing.T) {
+	repo := db.NewMemoryUserRepository()
+	ctx := context.Background()
+
+	_, notFound := repo.GetByID(ctx, 1)
+	invalid := repo.Create(ctx, &db.User{Email: "not-an-email", Username: "someone"})
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"NotFound", notFound, http.StatusNotFound},
+		{"Conflict", fmt.Errorf("create: %w", db.ErrConflict), http.StatusConflict},
+		{"Validation", invalid, http.StatusUnprocessableEntity},
+		{"InvalidQuery", db.ErrInvalidUserQuery, http.StatusUnprocessableEntity},
+		{"Veto", &db.UserHookVetoError{Operation: db.UserOperationCreate, Reason: "reserved"}, http.StatusUnprocessableEntity},
+		{"Unauthenticated", session.ErrUnauthenticated, http.StatusUnauthorized},
+		{"Forbidden", fmt.Errorf("%w: requires role admin", session.ErrForbidden), http.StatusForbidden},
+		{"Upstream", fmt.Errorf("%w: timeout", services.ErrUpstream), http.StatusBadGateway},
+		{"Timeout", fmt.Errorf("query users: %w", context.DeadlineExceeded), http.StatusServiceUnavailable},
+		{"Unknown", errors.New("pq: connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := ProblemFromError(tt.err)
+			assert.Equal(t, tt.status, problem.Status)
+			assert.Equal(t, http.StatusText(tt.status), problem.Title)
+		})
+	}
+
+	t.Run("ValidationFields", func(t *testing.T) {
+		problem := ProblemFromError(invalid)
+		assert.Equal(t, &[]api.FieldError{{Field: "email", Message: "must be a valid email address"}}, problem.Errors)
+	})
+
+	t.Run("InternalDetailsAreHidden", func(t *testing.T) {
+		problem := ProblemFromError(errors.New("pq: connection refused"))
+		assert.Nil(t, problem.Detail)
+	})
+
+	t.Run("VetoDetail", func(t *testing.T) {
+		problem := ProblemFromError(&db.UserHookVetoError{Operation: db.UserOperationCreate, Reason: "username is reserved"})
+		assert.Equal(t, "user create vetoed: username is reserved", *problem.Detail, "VetoUser reasons are public")
+
+		cause := errors.New("dial tcp 10.0.0.7:5432: connection refused")
+		problem = ProblemFromError(&db.UserHookVetoError{Operation: db.UserOperationCreate, Reason: cause.Error(), Err: cause})
+		assert.Equal(t, "user create was rejected", *problem.Detail)
+	})
+
+	t.Run("ClientClosedRequest", func(t *testing.T) {
+		problem := ProblemFromError(fmt.Errorf("query users: %w", context.Canceled))
+		assert.Equal(t, StatusClientClosedRequest, problem.Status)
+		assert.Equal(t, "Client Closed Request", problem.Title)
+	})
+}
+
+func TestProblemFromHookErrors(t *testing.T) {
+	repo := db.NewHookedUserRepository(db.NewMemoryUserRepository())
+	var hookErr error
+	repo.OnBeforeCreate(func(ctx context.Context, user *db.User) error {
+		return hookErr
+	})
+	s := NewStrictApiServer(repo, nil, nil, buildinfo.Info{})
+
+	tests := []struct {
+		name    string
+		hookErr error
+		status  int
+		fields  *[]api.FieldError
+	}{
+		{"NotFound", fmt.Errorf("%w: tenant 42 missing in billing-db.internal", db.ErrNotFound), http.StatusNotFound, nil},
+		{"Conflict", fmt.Errorf("%w: jane already in crm.internal", db.ErrConflict), http.StatusConflict, nil},
+		{
+			"Validation",
+			&db.ValidationError{Fields: []db.FieldError{{Field: "username", Message: "is reserved"}}},
+			http.StatusUnprocessableEntity,
+			&[]api.FieldError{{Field: "username", Message: "is reserved"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hookErr = tt.hookErr
+			_, err := s.CreateUser(context.Background(), api.CreateUserRequestObject{
+				Body: &api.UserCreate{Email: "jane@example.com", Username: "jane"},
+			})
+
+			w := httptest.NewRecorder()
+			ResponseErrorHandler(w, httptest.NewRequest(http.MethodPost, "/api/users", nil), err)
+			assert.Equal(t, tt.status, w.Code)
+			problem := decodeProblem(t, w)
+			assert.Equal(t, "user create was rejected", *problem.Detail, "The text of the hook error is not public")
+			assert.Equal(t, tt.fields, problem.Errors)
+		})
+	}
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) api.Problem {
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+
+	var problem api.Problem
+	err := json.Unmarshal(w.Body.Bytes(), &problem)
+	assert.NoError(t, err)
+	assert.Equal(t, w.Code, problem.Status)
+	return problem
+}
+
+func TestResponseErrorHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+	// Set by StrictInjectHTTPRequestMiddleware
+	w.Header().Set(session.HeaderRequestID, "req-123")
+
+	ResponseErrorHandler(w, r, fmt.Errorf("user %w", db.ErrNotFound))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "user not found", *problem.Detail)
+	assert.Equal(t, "/api/users/1", *problem.Instance)
+	assert.Equal(t, "req-123", *problem.RequestId)
+}
+
+func TestRequestErrorHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/users", nil)
+	r.Header.Set(session.HeaderRequestID, "req-456")
+
+	// The request ID comes from the context set by RequestIDMiddleware
+	handler := session.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestErrorHandler(w, r, errors.New("can't decode JSON body: invalid character '}' looking for beginning of value"))
+	}))
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, "The request body could not be decoded", *problem.Detail, "Decoder errors must not leak")
+	assert.Equal(t, "req-456", *problem.RequestId)
+}
+
+func TestParameterErrorHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/users/abc", nil)
+
+	ParameterErrorHandler(w, r, &api.InvalidParamFormatError{ParamName: "id", Err: errors.New("strconv.ParseUint: parsing \"abc\": invalid syntax")})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, "Invalid format for parameter id", *problem.Detail)
+	assert.Nil(t, problem.RequestId)
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+
+	"github.com/jmaister/gots-template/db"
+	client "github.com/jmaister/taronja-gateway-clients/go"
+	"gorm.io/gorm"
+)
+
+// DatabaseChecker pings the database through the connection pool
+func DatabaseChecker(sqlDB *sql.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// SchemaChecker verifies that every migration of the application is applied. The migrations and
+// their checksums are loaded once, each check only reads schema_migrations.
+func SchemaChecker(gormDB *gorm.DB) (Checker, error) {
+	migrations, err := db.Migrations(gormDB.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	migrator := db.NewMigrator(gormDB, migrations, slog.New(slog.DiscardHandler))
+	return CheckerFunc(migrator.Verify), nil
+}
+
+// GatewayChecker calls the health endpoint of Taronja Gateway
+func GatewayChecker(taronjaClient *client.ClientWithResponses) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		resp, err := taronjaClient.HealthCheckWithResponse(ctx)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("gateway health check returned %s", resp.Status())
+		}
+		return nil
+	})
+}
+
+// DiskSpaceChecker fails when the file system of the file at path, like the SQLite database,
+// has less than minFreeBytes available
+func DiskSpaceChecker(path string, minFreeBytes uint64) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		free, err := freeDiskSpace(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("%d MiB free, %d MiB required", free>>20, minFreeBytes>>20)
+		}
+		return nil
+	})
+}
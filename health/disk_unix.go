@@ -0,0 +1,18 @@
+//go:build unix
+
+package health
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// freeDiskSpace returns the bytes available to unprivileged users on the file system of dir
+func freeDiskSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(dir, &stat)
+	if err != nil {
+		return 0, fmt.Errorf("error reading free disk space: %w", err)
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,172 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Statuses of a check result and of a report
+const (
+	StatusOK = "ok"
+	// StatusDegraded means a non critical check failed, the application still serves requests
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// Checker checks a dependency of the application, returning an error when it is not usable.
+// It must return once ctx is done.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Name   string
+	Status string
+	// Critical checks make the application not ready when they fail
+	Critical bool
+	Latency  time.Duration
+	// Error is why the check failed, empty when it passed. It may name hosts, URLs or paths.
+	Error string
+	// TimedOut is true when the check failed because it ran out of time
+	TimedOut  bool
+	CheckedAt time.Time
+	// Cached is true when the result of a previous run was reused
+	Cached bool
+}
+
+// Report is the outcome of every registered check
+type Report struct {
+	// Status is fail when a critical check failed, degraded when another check failed and ok otherwise
+	Status string
+	Checks []Result
+}
+
+// check is a registered checker and its last result
+type check struct {
+	name     string
+	critical bool
+	checker  Checker
+
+	// mu is held while the check runs, so concurrent probes share a single run
+	mu     sync.Mutex
+	last   Result
+	hasRun bool
+}
+
+// Registry runs the health checks of the application. Each check is bounded by a timeout and
+// its result is reused for a while, so frequent probes do not overload the dependencies.
+type Registry struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.Mutex
+	checks []*check
+}
+
+// NewRegistry creates an empty registry running each check for at most timeout and reusing
+// its result for cacheTTL, 0 runs the checks on every call
+func NewRegistry(timeout time.Duration, cacheTTL time.Duration) *Registry {
+	return &Registry{
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// Register adds a check. Names must be unique.
+func (r *Registry) Register(name string, critical bool, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.checks {
+		if existing.name == name {
+			panic("health: duplicate check " + name)
+		}
+	}
+	r.checks = append(r.checks, &check{name: name, critical: critical, checker: checker})
+}
+
+// Check runs every check concurrently and reports their results in registration order.
+// A nil registry reports ok without checks.
+func (r *Registry) Check(ctx context.Context) Report {
+	if r == nil {
+		return Report{Status: StatusOK, Checks: []Result{}}
+	}
+
+	r.mu.Lock()
+	checks := make([]*check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run runs a check, or returns its last result while it is fresh
+func (r *Registry) run(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hasRun && time.Since(c.last.CheckedAt) < r.cacheTTL {
+		cached := c.last
+		cached.Cached = true
+		return cached
+	}
+
+	// The result is shared with other probes, so a probe that gives up does not cancel the check
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.checker.Check(checkCtx)
+	result := Result{
+		Name:      c.name,
+		Status:    StatusOK,
+		Critical:  c.critical,
+		Latency:   time.Since(start),
+		CheckedAt: start,
+	}
+	if err != nil {
+		if errors.Is(checkCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s: %w", r.timeout, err)
+			result.TimedOut = true
+		}
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	c.last = result
+	c.hasRun = true
+	return result
+}
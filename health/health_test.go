@@ -0,0 +1,155 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/db"
+	client "github.com/jmaister/taronja-gateway-clients/go"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+// result returns the result of the named check in a report
+func result(t *testing.T, report Report, name string) Result {
+	for _, r := range report.Checks {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("No result for check %s", name)
+	return Result{}
+}
+
+func TestRegistry(t *testing.T) {
+	ok := CheckerFunc(func(ctx context.Context) error { return nil })
+	failing := CheckerFunc(func(ctx context.Context) error { return errors.New("connection refused") })
+
+	t.Run("Status", func(t *testing.T) {
+		registry := NewRegistry(time.Second, 0)
+		registry.Register("database", true, ok)
+		registry.Register("gateway", false, failing)
+
+		report := registry.Check(context.Background())
+		assert.Equal(t, StatusDegraded, report.Status, "A non critical failure degrades")
+		assert.Equal(t, []string{"database", "gateway"}, []string{report.Checks[0].Name, report.Checks[1].Name})
+		assert.Equal(t, StatusOK, result(t, report, "database").Status)
+		gateway := result(t, report, "gateway")
+		assert.Equal(t, StatusFail, gateway.Status)
+		assert.Equal(t, "connection refused", gateway.Error)
+		assert.False(t, gateway.Critical)
+
+		registry.Register("schema", true, failing)
+		assert.Equal(t, StatusFail, registry.Check(context.Background()).Status)
+
+		assert.Panics(t, func() { registry.Register("schema", true, ok) })
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		registry := NewRegistry(20*time.Millisecond, 0)
+		registry.Register("slow", true, CheckerFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+
+		start := time.Now()
+		report := registry.Check(context.Background())
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, StatusFail, report.Status)
+		assert.Contains(t, report.Checks[0].Error, "timed out after 20ms")
+		assert.True(t, report.Checks[0].TimedOut)
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+		var runs atomic.Int32
+		registry := NewRegistry(time.Second, time.Hour)
+		registry.Register("counted", true, CheckerFunc(func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		}))
+
+		first := registry.Check(context.Background())
+		second := registry.Check(context.Background())
+		assert.Equal(t, int32(1), runs.Load())
+		assert.False(t, first.Checks[0].Cached)
+		assert.True(t, second.Checks[0].Cached)
+		assert.Equal(t, first.Checks[0].CheckedAt, second.Checks[0].CheckedAt)
+	})
+
+	t.Run("CanceledProbe", func(t *testing.T) {
+		registry := NewRegistry(time.Second, time.Hour)
+		registry.Register("database", true, CheckerFunc(func(ctx context.Context) error {
+			return ctx.Err()
+		}))
+
+		// A probe that gives up does not fail the shared result
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Equal(t, StatusOK, registry.Check(ctx).Status)
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		var registry *Registry
+		report := registry.Check(context.Background())
+		assert.Equal(t, StatusOK, report.Status)
+		assert.Empty(t, report.Checks)
+	})
+}
+
+func TestCheckers(t *testing.T) {
+	t.Run("Database", func(t *testing.T) {
+		sqlDB, err := sql.Open("sqlite", ":memory:")
+		assert.NoError(t, err)
+		assert.NoError(t, DatabaseChecker(sqlDB).Check(context.Background()))
+
+		sqlDB.Close()
+		assert.Error(t, DatabaseChecker(sqlDB).Check(context.Background()))
+	})
+
+	t.Run("Schema", func(t *testing.T) {
+		gormDB, err := db.Open(config.DatabaseConfig{URL: "sqlite://" + filepath.Join(t.TempDir(), "schema.db")}, slog.New(slog.DiscardHandler))
+		assert.NoError(t, err)
+		sqlDB, err := gormDB.DB()
+		assert.NoError(t, err)
+		defer sqlDB.Close()
+		checker, err := SchemaChecker(gormDB)
+		assert.NoError(t, err)
+		assert.Error(t, checker.Check(context.Background()), "Nothing is migrated")
+
+		migrations, err := db.Migrations(gormDB.Dialector.Name())
+		assert.NoError(t, err)
+		assert.NoError(t, db.NewMigrator(gormDB, migrations, slog.New(slog.DiscardHandler)).Up())
+		assert.NoError(t, checker.Check(context.Background()))
+	})
+
+	t.Run("Gateway", func(t *testing.T) {
+		status := http.StatusOK
+		gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/_/health", r.URL.Path)
+			w.WriteHeader(status)
+		}))
+		defer gateway.Close()
+		taronjaClient, err := client.NewClientWithResponses(gateway.URL + "/_/")
+		assert.NoError(t, err)
+
+		assert.NoError(t, GatewayChecker(taronjaClient).Check(context.Background()))
+
+		status = http.StatusServiceUnavailable
+		assert.ErrorContains(t, GatewayChecker(taronjaClient).Check(context.Background()), "503")
+	})
+
+	t.Run("DiskSpace", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.db")
+		assert.NoError(t, DiskSpaceChecker(path, 1).Check(context.Background()))
+		assert.ErrorContains(t, DiskSpaceChecker(path, 1<<62).Check(context.Background()), "MiB free")
+	})
+}
@@ -0,0 +1,186 @@
+package logging
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/session"
+)
+
+// redacted replaces the values that must not be logged
+const redacted = "[REDACTED]"
+
+// accessLog writes one record for every HTTP request
+type accessLog struct {
+	logger        *slog.Logger
+	headers       []string
+	redactHeaders map[string]bool
+	redactParams  map[string]bool
+	redactPaths   [][]string
+	samplePaths   []string
+	sampleEvery   uint64
+	sampled       atomic.Uint64
+}
+
+// NewAccessLogMiddleware returns a net/http middleware logging the method, path, operation, status,
+// bytes written, duration, user, request ID and remote IP of every request. It must run inside
+// session.RequestIDMiddleware so the records carry the request ID.
+func NewAccessLogMiddleware(logger *slog.Logger, cfg config.AccessLogConfig) func(http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	a := &accessLog{
+		logger:        logger,
+		headers:       cfg.Headers,
+		redactHeaders: make(map[string]bool),
+		redactParams:  make(map[string]bool),
+		samplePaths:   cfg.SamplePaths,
+		sampleEvery:   uint64(max(cfg.SampleEvery, 1)),
+	}
+	for _, header := range cfg.RedactHeaders {
+		a.redactHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+	for _, param := range cfg.RedactQueryParams {
+		a.redactParams[strings.ToLower(param)] = true
+	}
+	for _, template := range cfg.RedactPaths {
+		a.redactPaths = append(a.redactPaths, strings.Split(template, "/"))
+	}
+	return a.middleware
+}
+
+func (a *accessLog) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, requestContext := session.TrackRequestContext(r.Context())
+		recorder := session.NewResponseRecorder(w)
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.Status()
+		if status < http.StatusBadRequest && !a.sample(r.URL.Path) {
+			return
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", a.redactPath(r.URL)),
+			slog.Int("status", status),
+			slog.Int64("bytes", recorder.Bytes()),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_ip", remoteIP(r)),
+		}
+		reqCtx := requestContext()
+		if reqCtx != nil {
+			attrs = append(attrs, slog.String("operation", reqCtx.Operation))
+			if reqCtx.UserID != "" {
+				attrs = append(attrs, slog.String("user_id", reqCtx.UserID))
+			}
+		}
+		if len(a.headers) > 0 {
+			attrs = append(attrs, slog.Attr{Key: "headers", Value: slog.GroupValue(a.headerAttrs(r)...)})
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelWarn
+		}
+		// The request ID comes from the context
+		a.logger.LogAttrs(r.Context(), level, "HTTP request", attrs...)
+	})
+}
+
+// sample reports whether a successful request is logged, one in sampleEvery of the sampled paths
+func (a *accessLog) sample(path string) bool {
+	for _, prefix := range a.samplePaths {
+		if strings.HasPrefix(path, prefix) {
+			return (a.sampled.Add(1)-1)%a.sampleEvery == 0
+		}
+	}
+	return true
+}
+
+// redactPath returns the path and query of the request, with the segments and the values of the
+// redacted parameters replaced
+func (a *accessLog) redactPath(u *url.URL) string {
+	path := a.redactSegments(u.Path)
+	if u.RawQuery == "" {
+		return path
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		// The query cannot be redacted reliably, leave it out
+		return path + "?" + redacted
+	}
+	for name, values := range query {
+		if a.redactParams[strings.ToLower(name)] {
+			for i := range values {
+				values[i] = redacted
+			}
+		}
+	}
+	return path + "?" + query.Encode()
+}
+
+// redactSegments replaces the {name} segments of the first template matching path. A template matches
+// the paths with as many segments, equal to its own except where it has {name} or *.
+func (a *accessLog) redactSegments(path string) string {
+	segments := strings.Split(path, "/")
+	for _, template := range a.redactPaths {
+		if len(template) != len(segments) || !matchSegments(template, segments) {
+			continue
+		}
+		for i, segment := range template {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				segments[i] = redacted
+			}
+		}
+		return strings.Join(segments, "/")
+	}
+	return path
+}
+
+// matchSegments reports whether the segments of a path match those of a template of the same length
+func matchSegments(template []string, segments []string) bool {
+	for i, segment := range template {
+		wildcard := segment == "*" || strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+		if !wildcard && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *accessLog) headerAttrs(r *http.Request) []slog.Attr {
+	var attrs []slog.Attr
+	for _, header := range a.headers {
+		value := r.Header.Get(header)
+		if value == "" {
+			continue
+		}
+		if a.redactHeaders[http.CanonicalHeaderKey(header)] {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(header, value))
+	}
+	return attrs
+}
+
+// remoteIP returns the IP of the client connection, the gateway when requests come through it
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
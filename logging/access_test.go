@@ -0,0 +1,151 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/session"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveLogged serves a request through the access log and returns the records written
+func serveLogged(t *testing.T, cfg config.AccessLogConfig, handler http.Handler, r *http.Request) []map[string]interface{} {
+	var buf bytes.Buffer
+	logger := NewWithWriter(config.LogConfig{Format: config.LogFormatJSON, Level: config.LogLevelInfo}, &buf)
+	session.RequestIDMiddleware(NewAccessLogMiddleware(logger, cfg)(handler)).ServeHTTP(httptest.NewRecorder(), r)
+
+	var records []map[string]interface{}
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var record map[string]interface{}
+		assert.NoError(t, decoder.Decode(&record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestAccessLog(t *testing.T) {
+	cfg := config.Default().AccessLog
+
+	t.Run("Operation", func(t *testing.T) {
+		strict := session.StrictInjectHTTPRequestMiddleware(func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+			w.WriteHeader(http.StatusCreated)
+			_, err := w.Write([]byte(`{"id":1}`))
+			return nil, err
+		}, "CreateUser")
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := strict(r.Context(), w, r, nil)
+			assert.NoError(t, err)
+		})
+
+		r := httptest.NewRequest(http.MethodPost, "/api/users", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set(session.HeaderRequestID, "req-123")
+		r.Header.Set(session.HeaderUserId, "user-1")
+		r.Header.Set("User-Agent", "test-agent")
+		records := serveLogged(t, cfg, handler, r)
+
+		assert.Len(t, records, 1)
+		record := records[0]
+		assert.Equal(t, "HTTP request", record["msg"])
+		assert.Equal(t, "POST", record["method"])
+		assert.Equal(t, "/api/users", record["path"])
+		assert.Equal(t, "CreateUser", record["operation"])
+		assert.Equal(t, float64(http.StatusCreated), record["status"])
+		assert.Equal(t, float64(8), record["bytes"])
+		assert.Contains(t, record, "duration")
+		assert.Equal(t, "user-1", record["user_id"])
+		assert.Equal(t, "req-123", record["request_id"])
+		assert.Equal(t, "10.0.0.1", record["remote_ip"])
+		assert.Equal(t, map[string]interface{}{"User-Agent": "test-agent"}, record["headers"])
+	})
+
+	t.Run("SPA", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := w.Write([]byte("<html></html>"))
+			assert.NoError(t, err)
+		})
+		records := serveLogged(t, cfg, handler, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+		assert.Len(t, records, 1)
+		assert.Equal(t, float64(http.StatusOK), records[0]["status"])
+		assert.NotContains(t, records[0], "operation")
+		assert.NotContains(t, records[0], "user_id")
+	})
+
+	t.Run("Redaction", func(t *testing.T) {
+		cfg := cfg
+		cfg.Headers = []string{"User-Agent", "authorization"}
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+		r := httptest.NewRequest(http.MethodGet, "/callback?state=abc&Token=secret-value", nil)
+		r.Header.Set("Authorization", "Bearer secret-value")
+		records := serveLogged(t, cfg, handler, r)
+
+		assert.Len(t, records, 1)
+		assert.Equal(t, "/callback?Token=%5BREDACTED%5D&state=abc", records[0]["path"])
+		assert.Equal(t, map[string]interface{}{"authorization": "[REDACTED]"}, records[0]["headers"])
+	})
+
+	t.Run("PathRedaction", func(t *testing.T) {
+		cfg := cfg
+		cfg.RedactPaths = []string{"/reset/{token}", "/api/invitations/{token}/*"}
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+		tests := []struct {
+			path string
+			want string
+		}{
+			{"/reset/secret-value", "/reset/[REDACTED]"},
+			{"/reset/secret-value?token=abc", "/reset/[REDACTED]?token=%5BREDACTED%5D"},
+			{"/api/invitations/secret-value/accept", "/api/invitations/[REDACTED]/accept"},
+			{"/api/invitations/secret-value", "/api/invitations/secret-value"},
+			{"/reset", "/reset"},
+			{"/api/users/42", "/api/users/42"},
+		}
+		for _, tt := range tests {
+			records := serveLogged(t, cfg, handler, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Len(t, records, 1)
+			assert.Equal(t, tt.want, records[0]["path"], tt.path)
+		}
+	})
+
+	t.Run("Sampling", func(t *testing.T) {
+		cfg := cfg
+		cfg.SampleEvery = 3
+		status := http.StatusOK
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		})
+
+		var buf bytes.Buffer
+		logger := NewWithWriter(config.LogConfig{Format: config.LogFormatText, Level: config.LogLevelInfo}, &buf)
+		middleware := NewAccessLogMiddleware(logger, cfg)(handler)
+		for i := 0; i < 6; i++ {
+			middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/health", nil))
+		}
+		assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("\n")), "One in three requests is logged")
+
+		// Failed requests are always logged
+		buf.Reset()
+		status = http.StatusServiceUnavailable
+		for i := 0; i < 3; i++ {
+			middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/health", nil))
+		}
+		assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("\n")))
+		assert.Contains(t, buf.String(), "level=WARN")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		cfg := cfg
+		cfg.Enabled = false
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+		records := serveLogged(t, cfg, handler, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Empty(t, records)
+	})
+}
@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/session"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New creates the logger configured in cfg, writing to cfg.File or to standard error.
+// Close the returned io.Closer once nothing logs anymore to close the log file.
+func New(cfg config.LogConfig) (*slog.Logger, io.Closer, error) {
+	if cfg.File == "" {
+		return NewWithWriter(cfg, os.Stderr), nopCloser{}, nil
+	}
+
+	file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening log file: %w", err)
+	}
+	return NewWithWriter(cfg, file), file, nil
+}
+
+// NewWithWriter creates the logger configured in cfg writing to w, ignoring cfg.File
+func NewWithWriter(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	options := &slog.HandlerOptions{
+		AddSource:   true,
+		Level:       ParseLevel(cfg.Level),
+		ReplaceAttr: shortSource,
+	}
+
+	var handler slog.Handler
+	if cfg.Format == config.LogFormatJSON {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// ParseLevel returns the slog level of a configured level, info when unknown
+func ParseLevel(level string) slog.Level {
+	switch level {
+	case config.LogLevelDebug:
+		return slog.LevelDebug
+	case config.LogLevelWarn:
+		return slog.LevelWarn
+	case config.LogLevelError:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// shortSource logs the source as file.go:line instead of the full path and function
+func shortSource(groups []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.SourceKey || len(groups) > 0 {
+		return a
+	}
+	source, ok := a.Value.Any().(*slog.Source)
+	if !ok || source.File == "" {
+		return a
+	}
+	return slog.String(slog.SourceKey, filepath.Base(source.File)+":"+strconv.Itoa(source.Line))
+}
+
+// contextHandler adds the attributes of the request to the records logged with a request context:
+// request_id, trace_id and span_id when the request is traced, and user_id and operation once the
+// strict middlewares created the session.RequestContext
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	requestID := session.RequestIDFromContext(ctx)
+	if requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+
+	spanContext := trace.SpanContextFromContext(ctx)
+	if spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	reqCtx, err := session.GetRequestContext(ctx)
+	if err == nil {
+		if reqCtx.UserID != "" {
+			record.AddAttrs(slog.String("user_id", reqCtx.UserID))
+		}
+		if reqCtx.Operation != "" {
+			record.AddAttrs(slog.String("operation", reqCtx.Operation))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/session"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestNewWithWriter(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := NewWithWriter(config.LogConfig{Format: config.LogFormatJSON, Level: config.LogLevelInfo}, &buf)
+
+		logger.Debug("Hidden")
+		logger.Info("Shown", "answer", 42)
+
+		var record map[string]interface{}
+		err := json.Unmarshal(buf.Bytes(), &record)
+		assert.NoError(t, err, "Only the info record is logged")
+		assert.Equal(t, "Shown", record["msg"])
+		assert.Equal(t, float64(42), record["answer"])
+		assert.Regexp(t, `^logging_test\.go:\d+$`, record["source"])
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := NewWithWriter(config.LogConfig{Format: config.LogFormatText, Level: config.LogLevelWarn}, &buf)
+
+		logger.Info("Hidden")
+		logger.Warn("Shown", "answer", 42)
+		assert.Regexp(t, `^time=\S+ level=WARN source=logging_test\.go:\d+ msg=Shown answer=42\n$`, buf.String())
+	})
+}
+
+func TestRequestAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithWriter(config.LogConfig{Format: config.LogFormatJSON, Level: config.LogLevelInfo}, &buf)
+
+	// The strict middleware adds the user and the operation to the request context
+	strict := session.StrictInjectHTTPRequestMiddleware(func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		logger.InfoContext(ctx, "In handler")
+		return nil, nil
+	}, "GetUser")
+	handler := session.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "Before routing")
+		_, err := strict(r.Context(), w, r, nil)
+		assert.NoError(t, err)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+	r.Header.Set(session.HeaderRequestID, "req-123")
+	r.Header.Set(session.HeaderUserId, "user-1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	decoder := json.NewDecoder(&buf)
+	var before, inHandler map[string]interface{}
+	assert.NoError(t, decoder.Decode(&before))
+	assert.NoError(t, decoder.Decode(&inHandler))
+
+	assert.Equal(t, "req-123", before["request_id"])
+	assert.NotContains(t, before, "user_id")
+	assert.NotContains(t, before, "operation")
+
+	assert.Equal(t, "req-123", inHandler["request_id"])
+	assert.Equal(t, "user-1", inHandler["user_id"])
+	assert.Equal(t, "GetUser", inHandler["operation"])
+}
+
+func TestTraceAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewWithWriter(config.LogConfig{Format: config.LogFormatJSON, Level: config.LogLevelInfo}, &buf)
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "GetUser")
+	defer span.End()
+	logger.InfoContext(ctx, "Traced")
+	logger.Info("Untraced")
+
+	decoder := json.NewDecoder(&buf)
+	var traced, untraced map[string]interface{}
+	assert.NoError(t, decoder.Decode(&traced))
+	assert.NoError(t, decoder.Decode(&untraced))
+
+	assert.Equal(t, span.SpanContext().TraceID().String(), traced["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), traced["span_id"])
+	assert.NotContains(t, untraced, "trace_id")
+}
+
+func TestNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger, closer, err := New(config.LogConfig{Format: config.LogFormatText, Level: config.LogLevelInfo, File: path})
+	assert.NoError(t, err)
+
+	logger.Info("Written to the file")
+	assert.NoError(t, closer.Close())
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "msg=\"Written to the file\"")
+
+	_, _, err = New(config.LogConfig{File: filepath.Join(t.TempDir(), "missing", "app.log")})
+	assert.Error(t, err)
+}
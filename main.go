@@ -2,12 +2,15 @@ package main
 
 import (
 	"embed"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 
+	"github.com/jmaister/gots-template/buildinfo"
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/logging"
 	"github.com/jmaister/gots-template/server"
-	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 )
 
@@ -31,17 +34,44 @@ var rootCmd = &cobra.Command{
 	Long:  `A CLI for managing and running the GOTS Template application.`,
 }
 
+// buildInfo returns the build information of the binary, from the injected variables and the Go toolchain
+func buildInfo() buildinfo.Info {
+	return buildinfo.New(buildinfo.Ldflags{
+		Version: version,
+		Commit:  commit,
+		Date:    date,
+		OS:      buildOS,
+		Arch:    buildArch,
+	})
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version information",
-	Long:  `Display version, commit, build date, and build platform information.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Display version, commit, build date, and build platform information.
+With --json the Go version and the modules the binary was built with are included.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := buildInfo()
+
+		asJSON, _ := cmd.Flags().GetBool("json")
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(info)
+		}
+
+		commit := info.Commit
+		if info.Dirty {
+			commit += " (dirty)"
+		}
 		fmt.Printf("GOTS Template\n")
-		fmt.Printf("Version: %s\n", version)
+		fmt.Printf("Version: %s\n", info.Version)
 		fmt.Printf("Commit: %s\n", commit)
-		fmt.Printf("Build Date: %s\n", date)
-		fmt.Printf("Build OS: %s\n", buildOS)
-		fmt.Printf("Build Arch: %s\n", buildArch)
+		fmt.Printf("Build Date: %s\n", info.Date)
+		fmt.Printf("Build OS: %s\n", info.OS)
+		fmt.Printf("Build Arch: %s\n", info.Arch)
+		fmt.Printf("Go Version: %s\n", info.GoVersion)
+		return nil
 	},
 }
 
@@ -50,13 +80,21 @@ var runCmd = &cobra.Command{
 	Short: "Run the GOTS Template application",
 	Long:  `Starts the GOTS Template application.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		runServer()
+		runServer(cmd)
 	},
 }
 
 func init() {
+	versionCmd.Flags().Bool("json", false, "Print the build information as JSON, with the module dependencies")
+
+	runCmd.Flags().String("config", "", "Path to the YAML config file (also CONFIG_FILE)")
+	config.BindFlags(runCmd.Flags())
+
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(rolesCmd)
 }
 
 // --- Main Function ---
@@ -68,25 +106,42 @@ func main() {
 	}
 }
 
-func runServer() {
-	err := godotenv.Load() // 👈 load .env file
+func runServer(cmd *cobra.Command) {
+	// Only used until the configured logger is created
+	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile) // Include file/line number
+
+	configFile, _ := cmd.Flags().GetString("config")
+	appConfig, err := config.Load(config.LoadOptions{
+		ConfigFile: configFile,
+		Flags:      cmd.Flags(),
+	})
 	if err != nil {
-		log.Printf("Warning: Failed to load .env file: %v", err)
+		log.Fatalf("FATAL: %v", err)
 	}
 
-	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile) // Include file/line number
-	log.Println("Starting server...")
+	logger, logFile, err := logging.New(appConfig.Log)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	defer logFile.Close()
+
+	logger.Info("Starting server...")
 
 	// Create and start the server
-	srv, err := server.NewServerFromEmbedFS("127.0.0.1", 8081, webappEmbedFS, "webapp/dist")
+	srv, err := server.NewServerFromEmbedFS(appConfig, logger, buildInfo(), webappEmbedFS, "webapp/dist")
 	if err != nil {
-		log.Fatalf("FATAL: Failed to create server: %v", err)
+		logger.Error("Failed to create server", "error", err)
+		logFile.Close()
+		os.Exit(1)
 	}
 
-	err = srv.Start()
+	// Blocks until SIGINT/SIGTERM, then drains in-flight requests
+	err = srv.Run()
 	if err != nil {
-		log.Fatalf("FATAL: Failed to start server: %v", err)
+		logger.Error("Server stopped with error", "error", err)
+		logFile.Close()
+		os.Exit(1)
 	}
 
-	log.Println("Server shut down gracefully.")
+	logger.Info("Server shut down gracefully.")
 }
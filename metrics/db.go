@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"database/sql"
+)
+
+// RegisterDBStats exposes the statistics of a connection pool, read on every scrape
+func RegisterDBStats(r *Registry, db *sql.DB) {
+	r.NewGaugeFunc("db_max_open_connections", "Maximum number of open connections to the database.", func() float64 {
+		return float64(db.Stats().MaxOpenConnections)
+	})
+	r.NewGaugeFunc("db_open_connections", "Established connections to the database, in use and idle.", func() float64 {
+		return float64(db.Stats().OpenConnections)
+	})
+	r.NewGaugeFunc("db_in_use_connections", "Connections to the database currently in use.", func() float64 {
+		return float64(db.Stats().InUse)
+	})
+	r.NewGaugeFunc("db_idle_connections", "Idle connections to the database.", func() float64 {
+		return float64(db.Stats().Idle)
+	})
+	r.NewCounterFunc("db_wait_count_total", "Connections waited for because the pool was exhausted.", func() float64 {
+		return float64(db.Stats().WaitCount)
+	})
+	r.NewCounterFunc("db_wait_duration_seconds_total", "Time spent waiting for a connection.", func() float64 {
+		return db.Stats().WaitDuration.Seconds()
+	})
+	r.NewCounterFunc("db_max_idle_closed_total", "Connections closed because of the maximum of idle connections.", func() float64 {
+		return float64(db.Stats().MaxIdleClosed)
+	})
+	r.NewCounterFunc("db_max_lifetime_closed_total", "Connections closed because of their maximum lifetime.", func() float64 {
+		return float64(db.Stats().MaxLifetimeClosed)
+	})
+}
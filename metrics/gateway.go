@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jmaister/gots-template/session"
+)
+
+// Results of the gateway calls
+const (
+	resultSuccess         = "success"
+	resultUnauthenticated = "unauthenticated"
+	resultError           = "error"
+)
+
+// GatewayMetrics counts the calls to Taronja Gateway and their latency per client operation
+type GatewayMetrics struct {
+	requests *Counter
+	duration *Histogram
+}
+
+// NewGatewayMetrics registers the gateway client metrics
+func NewGatewayMetrics(r *Registry) *GatewayMetrics {
+	return &GatewayMetrics{
+		requests: r.NewCounter("gateway_requests_total", "Calls to Taronja Gateway by client operation and result: success, unauthenticated or error.", "operation", "result"),
+		duration: r.NewHistogram("gateway_request_duration_seconds", "Latency of the calls to Taronja Gateway by client operation.", DefaultBuckets, "operation"),
+	}
+}
+
+// Observe records a call started at start that returned err. Unauthenticated users are not
+// gateway errors and canceled requests are not counted as errors either.
+// It is safe to call on a nil *GatewayMetrics.
+func (m *GatewayMetrics) Observe(ctx context.Context, operation string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	result := resultSuccess
+	switch {
+	case err == nil:
+	case errors.Is(err, session.ErrUnauthenticated):
+		result = resultUnauthenticated
+	case ctx.Err() != nil:
+		// The client went away, the gateway did not fail
+		return
+	default:
+		result = resultError
+	}
+	m.requests.Inc(operation, result)
+	m.duration.Observe(time.Since(start).Seconds(), operation)
+}
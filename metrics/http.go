@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jmaister/gots-template/session"
+)
+
+// operationNone labels the requests that are not API operations, like the SPA and /metrics
+const operationNone = "none"
+
+// methodOther labels the requests with a method outside of knownMethods. Clients choose the
+// method, each new value would otherwise be a new series.
+const methodOther = "OTHER"
+
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// HTTPMetrics counts the HTTP requests and their latency per OpenAPI operation
+type HTTPMetrics struct {
+	requests *Counter
+	duration *Histogram
+	inFlight *Gauge
+}
+
+// NewHTTPMetrics registers the HTTP request metrics
+func NewHTTPMetrics(r *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: r.NewCounter("http_requests_total", "HTTP requests by OpenAPI operation, method and status code.", "operation", "method", "status"),
+		duration: r.NewHistogram("http_request_duration_seconds", "Latency of HTTP requests by OpenAPI operation.", DefaultBuckets, "operation"),
+		inFlight: r.NewGauge("http_requests_in_flight", "HTTP requests being served."),
+	}
+}
+
+// Middleware records the metrics of every request. The operation is the one matched by the
+// strict handler, "none" for requests that are not API operations.
+func (m *HTTPMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		ctx, requestContext := session.TrackRequestContext(r.Context())
+		recorder := session.NewResponseRecorder(w)
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		operation := operationNone
+		reqCtx := requestContext()
+		if reqCtx != nil {
+			operation = reqCtx.Operation
+		}
+		method := r.Method
+		if !knownMethods[method] {
+			method = methodOther
+		}
+		m.requests.Inc(operation, method, strconv.Itoa(recorder.Status()))
+		m.duration.Observe(time.Since(start).Seconds(), operation)
+	})
+}
@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmaister/gots-template/session"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+var sampleLine = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{((?:[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*",?)*)\})? (\S+)$`)
+
+// scrape parses the text exposition format the way Prometheus does and fails the test on any
+// malformed line. It returns the samples keyed by name{labels}.
+func scrape(t *testing.T, handler http.Handler) map[string]float64 {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+
+	types := make(map[string]string)
+	samples := make(map[string]float64)
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+		if strings.HasPrefix(line, "# TYPE ") {
+			fields := strings.Fields(line)
+			assert.Len(t, fields, 4, line)
+			assert.NotContains(t, types, fields[2], "Duplicate TYPE of %s", fields[2])
+			assert.Contains(t, []string{"counter", "gauge", "histogram"}, fields[3])
+			types[fields[2]] = fields[3]
+			continue
+		}
+
+		match := sampleLine.FindStringSubmatch(line)
+		if !assert.NotNil(t, match, "Malformed sample %q", line) {
+			continue
+		}
+		name := match[1]
+		family := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(name, "_bucket"), "_sum"), "_count")
+		_, typed := types[name]
+		_, typedFamily := types[family]
+		assert.True(t, typed || (typedFamily && types[family] == "histogram"), "Sample %s without TYPE", name)
+
+		value, err := strconv.ParseFloat(match[4], 64)
+		assert.NoError(t, err, line)
+		samples[name+"{"+match[3]+"}"] = value
+	}
+	return samples
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("test_events_total", "Events.\nWith a second line.", "kind")
+	gauge := registry.NewGauge("test_temperature", "Temperature.")
+	histogram := registry.NewHistogram("test_latency_seconds", "Latency.", []float64{0.1, 1}, "path")
+	registry.NewGaugeFunc("test_answer", "Answer.", func() float64 { return 42 })
+
+	counter.Inc(`quoted "value"`)
+	counter.Add(2, "plain")
+	gauge.Set(21.5)
+	histogram.Observe(0.05, "/a")
+	histogram.Observe(0.5, "/a")
+	histogram.Observe(5, "/a")
+
+	samples := scrape(t, registry.Handler())
+	assert.Equal(t, map[string]float64{
+		`test_events_total{kind="plain"}`:                  2,
+		`test_events_total{kind="quoted \"value\""}`:       1,
+		`test_temperature{}`:                               21.5,
+		`test_answer{}`:                                    42,
+		`test_latency_seconds_bucket{path="/a",le="0.1"}`:  1,
+		`test_latency_seconds_bucket{path="/a",le="1"}`:    2,
+		`test_latency_seconds_bucket{path="/a",le="+Inf"}`: 3,
+		`test_latency_seconds_sum{path="/a"}`:              5.55,
+		`test_latency_seconds_count{path="/a"}`:            3,
+	}, samples)
+
+	assert.Panics(t, func() { registry.NewCounter("test_events_total", "Again.") })
+	assert.Panics(t, func() { counter.Inc() }, "Label values are required")
+	assert.Panics(t, func() { counter.Add(-1, "plain") })
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	registry := NewRegistry()
+	httpMetrics := NewHTTPMetrics(registry)
+
+	strict := session.StrictInjectHTTPRequestMiddleware(func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		w.WriteHeader(http.StatusNotFound)
+		return nil, nil
+	}, "GetUser")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users/1", func(w http.ResponseWriter, r *http.Request) {
+		_, err := strict(r.Context(), w, r, nil)
+		assert.NoError(t, err)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("<html></html>"))
+		assert.NoError(t, err)
+	})
+	handler := httpMetrics.Middleware(mux)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("RANDOM1", "/users", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("RANDOM2", "/users", nil))
+
+	samples := scrape(t, registry.Handler())
+	assert.Equal(t, float64(2), samples[`http_requests_total{operation="GetUser",method="GET",status="404"}`])
+	assert.Equal(t, float64(1), samples[`http_requests_total{operation="none",method="GET",status="200"}`])
+	assert.Equal(t, float64(2), samples[`http_requests_total{operation="none",method="OTHER",status="200"}`], "Unknown methods share one series")
+	assert.Equal(t, float64(2), samples[`http_request_duration_seconds_count{operation="GetUser"}`])
+	assert.Equal(t, float64(0), samples[`http_requests_in_flight{}`])
+}
+
+func TestGatewayMetrics(t *testing.T) {
+	registry := NewRegistry()
+	gatewayMetrics := NewGatewayMetrics(registry)
+	ctx := context.Background()
+
+	gatewayMetrics.Observe(ctx, "GetCurrentUser", time.Now(), nil)
+	gatewayMetrics.Observe(ctx, "GetCurrentUser", time.Now(), session.ErrUnauthenticated)
+	gatewayMetrics.Observe(ctx, "GetCurrentUser", time.Now(), errors.New("connection refused"))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	gatewayMetrics.Observe(canceled, "GetCurrentUser", time.Now(), canceled.Err())
+
+	samples := scrape(t, registry.Handler())
+	for _, result := range []string{"success", "unauthenticated", "error"} {
+		assert.Equal(t, float64(1), samples[fmt.Sprintf(`gateway_requests_total{operation="GetCurrentUser",result="%s"}`, result)], result)
+	}
+	assert.Equal(t, float64(3), samples[`gateway_request_duration_seconds_count{operation="GetCurrentUser"}`])
+
+	// Services without metrics pass nil
+	var none *GatewayMetrics
+	none.Observe(ctx, "GetCurrentUser", time.Now(), nil)
+}
+
+func TestDBStats(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(3)
+	assert.NoError(t, db.Ping())
+
+	registry := NewRegistry()
+	RegisterDBStats(registry, db)
+
+	samples := scrape(t, registry.Handler())
+	assert.Equal(t, float64(3), samples[`db_max_open_connections{}`])
+	assert.Equal(t, float64(1), samples[`db_open_connections{}`])
+	assert.Equal(t, float64(1), samples[`db_idle_connections{}`])
+	assert.Equal(t, float64(0), samples[`db_wait_count_total{}`])
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/db"
+	"github.com/jmaister/gots-template/logging"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply, roll back and inspect database migrations",
+	Long:  `Manage the versioned database migrations tracked in the schema_migrations table.`,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply every pending migration",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		migrator, err := newMigrator(cmd)
+		if err != nil {
+			return err
+		}
+		return migrator.Up()
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [steps]",
+	Short: "Roll back the latest migrations (one by default)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		steps := 1
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return fmt.Errorf("steps must be a positive integer, got %q", args[0])
+			}
+			steps = n
+		}
+
+		migrator, err := newMigrator(cmd)
+		if err != nil {
+			return err
+		}
+		return migrator.Down(steps)
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List migrations and whether they are applied",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		migrator, err := newMigrator(cmd)
+		if err != nil {
+			return err
+		}
+
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	},
+}
+
+// newMigrator opens the configured database without migrating it
+func newMigrator(cmd *cobra.Command) (*db.Migrator, error) {
+	configFile, _ := cmd.Flags().GetString("config")
+	appConfig, err := config.Load(config.LoadOptions{ConfigFile: configFile})
+	if err != nil {
+		return nil, err
+	}
+
+	// Commands log to the terminal, not to the log file of the server
+	logger := logging.NewWithWriter(appConfig.Log, os.Stderr)
+	conn, err := db.Open(appConfig.Database, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := db.Migrations(conn.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return db.NewMigrator(conn, migrations, logger), nil
+}
+
+func init() {
+	migrateCmd.PersistentFlags().String("config", "", "Path to the YAML config file (also CONFIG_FILE)")
+
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+}
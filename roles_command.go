@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/db"
+	"github.com/jmaister/gots-template/logging"
+	"github.com/spf13/cobra"
+)
+
+var rolesCmd = &cobra.Command{
+	Use:   "roles",
+	Short: "Manage local roles, their permissions and their users",
+	Long: `Manage the local roles checked by the x-required-role and x-required-permissions
+policies of the API. Gateway admins pass every policy without any local role.`,
+}
+
+var rolesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List roles and their permissions",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		roles, _, err := newRoleRepositories(cmd)
+		if err != nil {
+			return err
+		}
+
+		list, err := roles.ListRoles(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tPERMISSIONS\tDESCRIPTION")
+		for _, role := range list {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", role.Name, strings.Join(role.PermissionNames(), ","), role.Description)
+		}
+		return w.Flush()
+	},
+}
+
+var rolesCreateCmd = &cobra.Command{
+	Use:   "create <role> [permission...]",
+	Short: "Create a role with permissions",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		roles, _, err := newRoleRepositories(cmd)
+		if err != nil {
+			return err
+		}
+
+		description, _ := cmd.Flags().GetString("description")
+		role := &db.Role{Name: args[0], Description: description}
+		for _, permission := range args[1:] {
+			role.Permissions = append(role.Permissions, db.RolePermission{Permission: permission})
+		}
+		return roles.CreateRole(cmd.Context(), role)
+	},
+}
+
+var rolesDeleteCmd = &cobra.Command{
+	Use:   "delete <role>",
+	Short: "Delete a role and its assignments",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		roles, _, err := newRoleRepositories(cmd)
+		if err != nil {
+			return err
+		}
+		return roles.DeleteRole(cmd.Context(), args[0])
+	},
+}
+
+var rolesGrantCmd = &cobra.Command{
+	Use:   "grant <role> <permission>",
+	Short: "Grant a permission to a role",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		roles, _, err := newRoleRepositories(cmd)
+		if err != nil {
+			return err
+		}
+		return roles.GrantPermission(cmd.Context(), args[0], args[1])
+	},
+}
+
+var rolesRevokeCmd = &cobra.Command{
+	Use:   "revoke <role> <permission>",
+	Short: "Revoke a permission from a role",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		roles, _, err := newRoleRepositories(cmd)
+		if err != nil {
+			return err
+		}
+		return roles.RevokePermission(cmd.Context(), args[0], args[1])
+	},
+}
+
+var rolesAssignCmd = &cobra.Command{
+	Use:   "assign <username> <role>",
+	Short: "Assign a role to a user",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		roles, users, err := newRoleRepositories(cmd)
+		if err != nil {
+			return err
+		}
+
+		user, err := users.GetByUsername(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		return roles.AssignRole(cmd.Context(), user.ID, args[1])
+	},
+}
+
+var rolesUnassignCmd = &cobra.Command{
+	Use:   "unassign <username> <role>",
+	Short: "Remove a role from a user",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		roles, users, err := newRoleRepositories(cmd)
+		if err != nil {
+			return err
+		}
+
+		user, err := users.GetByUsername(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		return roles.UnassignRole(cmd.Context(), user.ID, args[1])
+	},
+}
+
+// newRoleRepositories opens and migrates the configured database
+func newRoleRepositories(cmd *cobra.Command) (db.RoleRepository, db.UserRepository, error) {
+	configFile, _ := cmd.Flags().GetString("config")
+	appConfig, err := config.Load(config.LoadOptions{ConfigFile: configFile})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Commands log to the terminal, not to the log file of the server
+	err = db.Init(appConfig.Database, logging.NewWithWriter(appConfig.Log, os.Stderr))
+	if err != nil {
+		return nil, nil, err
+	}
+	conn := db.GetConnection()
+	return db.NewDBRoleRepository(conn), db.NewDBUserRepository(conn), nil
+}
+
+func init() {
+	rolesCmd.PersistentFlags().String("config", "", "Path to the YAML config file (also CONFIG_FILE)")
+	rolesCreateCmd.Flags().String("description", "", "Description of the role")
+
+	rolesCmd.AddCommand(rolesListCmd)
+	rolesCmd.AddCommand(rolesCreateCmd)
+	rolesCmd.AddCommand(rolesDeleteCmd)
+	rolesCmd.AddCommand(rolesGrantCmd)
+	rolesCmd.AddCommand(rolesRevokeCmd)
+	rolesCmd.AddCommand(rolesAssignCmd)
+	rolesCmd.AddCommand(rolesUnassignCmd)
+}
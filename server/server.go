@@ -1,81 +1,204 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/jmaister/gots-template/api"
+	"github.com/jmaister/gots-template/buildinfo"
+	"github.com/jmaister/gots-template/compress"
+	"github.com/jmaister/gots-template/config"
 	"github.com/jmaister/gots-template/db"
 	"github.com/jmaister/gots-template/handlers"
+	"github.com/jmaister/gots-template/health"
+	"github.com/jmaister/gots-template/logging"
+	"github.com/jmaister/gots-template/metrics"
 	"github.com/jmaister/gots-template/services"
 	"github.com/jmaister/gots-template/session"
+	"github.com/jmaister/gots-template/tracing"
 	client "github.com/jmaister/taronja-gateway-clients/go"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ServerConfig holds the server configuration
 type ServerConfig struct {
-	Host       string
-	Port       int
+	AppConfig *config.AppConfig
+	Logger    *slog.Logger
+	// BuildInfo describes the running binary, reported by /api/health and /api/version
+	BuildInfo  buildinfo.Info
 	WebappFS   embed.FS
 	WebappPath string
 }
 
+// ShutdownHook is a function run while the server is shutting down.
+// The context carries the shutdown deadline.
+type ShutdownHook func(ctx context.Context) error
+
+// namedShutdownHook pairs a shutdown hook with a name for logging
+type namedShutdownHook struct {
+	name string
+	hook ShutdownHook
+}
+
 // Server represents the main HTTP server with its dependencies
 type Server struct {
-	HTTPServer     *http.Server
-	Mux            *http.ServeMux
-	UserRepository db.UserRepository
-	MeService      *services.MeService
-	WebappFS       embed.FS
-	WebappPath     string
+	HTTPServer *http.Server
+	Mux        *http.ServeMux
+	Logger     *slog.Logger
+	// Metrics is exposed on /metrics, register application metrics on it
+	Metrics *metrics.Registry
+	// MetricsServer serves /metrics on metrics.addr, nil when it is served on the main port
+	MetricsServer *http.Server
+	// TracerProvider creates the spans of the requests, a no-op provider when tracing is disabled
+	TracerProvider trace.TracerProvider
+	// HealthChecks are run by /api/health/ready, register application checks on it
+	HealthChecks *health.Registry
+	BuildInfo    buildinfo.Info
+	// UserHooks is the user repository of the API handlers, register user lifecycle hooks on it
+	UserHooks *db.UserRepositoryHooked
+	// RoleRepository stores the local roles checked by the authorization policies of the API
+	RoleRepository  db.RoleRepository
+	MeService       *services.MeService
+	WebappFS        embed.FS
+	WebappPath      string
+	ShutdownTimeout time.Duration
+
+	hooksMu       sync.Mutex
+	shutdownHooks []namedShutdownHook
 }
 
-// NewServer creates and configures a new server instance
-func NewServer(serverConfig *ServerConfig) (*Server, error) {
+// NewServer creates and configures a new server instance. When a step fails, the database and the
+// tracer set up by the previous ones are closed.
+func NewServer(serverConfig *ServerConfig) (_ *Server, err error) {
+	appConfig := serverConfig.AppConfig
+	logger := serverConfig.Logger
+
+	tracerProvider, shutdownTracing, err := tracing.New(context.Background(), appConfig.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring tracing: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			shutdownTracing(context.Background())
+		}
+	}()
+
 	// Initialize the database connection
-	db.Init()
+	err = db.Init(appConfig.Database, logger)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing database: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			db.Close()
+		}
+	}()
 
 	mux := http.NewServeMux()
 
+	registry := metrics.NewRegistry()
+	httpMetrics := metrics.NewHTTPMetrics(registry)
+	gatewayMetrics := metrics.NewGatewayMetrics(registry)
+	sqlDB, err := db.GetConnection().DB()
+	if err != nil {
+		return nil, fmt.Errorf("error reading database pool: %w", err)
+	}
+	metrics.RegisterDBStats(registry, sqlDB)
+
+	// Identity headers are verified before routing, so no handler reads unverified ones
+	headerVerifier, err := session.NewHeaderVerifier(appConfig.Session)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring header verification: %w", err)
+	}
+	headerVerifier.OnReject = func(w http.ResponseWriter, r *http.Request, err error) {
+		handlers.WriteProblem(w, r, handlers.ProblemFromError(err))
+	}
+
 	// Create server handler
+	writeTimeout := 15 * time.Second
 	var handler http.Handler = mux
+	handler = headerVerifier.Middleware(handler)
+	// Inside the metrics and the access log so they count the bytes actually sent
+	handler = compress.NewMiddleware(appConfig.Compression)(handler)
+	// Outside the verifier so rejected requests still carry the CORS headers
+	handler = session.NewCORSMiddleware(appConfig.CORS)(handler)
+	if appConfig.Metrics.Enabled {
+		handler = httpMetrics.Middleware(handler)
+	}
+	// Outside CORS and the verifier so preflight and rejected requests are logged too
+	handler = logging.NewAccessLogMiddleware(logger, appConfig.AccessLog)(handler)
+	// Outside the other middlewares so every response, including SPA and rejected requests, carries X-Request-ID
+	handler = session.RequestIDMiddleware(handler)
+	if appConfig.Tracing.Exporter != config.TracingExporterNone {
+		// Outside the other middlewares so every record they log carries the trace ID
+		handler = tracing.Middleware(tracerProvider)(handler)
+	}
+	handler = session.LoggerMiddleware(logger)(handler)
+	handler = withRequestDeadline(handler, writeTimeout)
 
 	httpServer := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", serverConfig.Host, serverConfig.Port),
+		Addr:         appConfig.Server.Addr(),
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: writeTimeout,
 		IdleTimeout:  120 * time.Second,
 		Handler:      handler,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
 	}
 
 	// Create Taronja Gateway client
-	apiURL := os.Getenv("API_URL")
-	if apiURL == "" {
-		apiURL = "http://localhost:8080" // Default to local Taronja Gateway
-	}
-	apiURL = apiURL + "/_/" // Append Gateway API prefix
-	adminToken := os.Getenv("ADMIN_TOKEN")
+	apiURL := appConfig.Gateway.BaseURL() + "/_/" // Append Gateway API prefix
 	taronjaClient, err := client.NewClientWithResponses(apiURL)
 	if err != nil {
 		return nil, fmt.Errorf("error creating taronja client: %w", err)
 	}
 
-	meService := services.NewMeService(taronjaClient, adminToken)
+	meService := services.NewMeService(taronjaClient, appConfig.Gateway, gatewayMetrics)
+
+	healthChecks, err := newHealthChecks(appConfig, sqlDB, taronjaClient)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring health checks: %w", err)
+	}
 
-	userRepository := db.NewDBUserRepository(db.GetConnection())
+	// Wrapped so application code can register lifecycle hooks on user changes
+	userHooks := db.NewHookedUserRepository(db.NewDBUserRepository(db.GetConnection()))
 
 	server := &Server{
-		HTTPServer:     httpServer,
-		Mux:            mux,
-		UserRepository: userRepository,
-		MeService:      meService,
-		WebappFS:       serverConfig.WebappFS,
-		WebappPath:     serverConfig.WebappPath,
+		HTTPServer:      httpServer,
+		Mux:             mux,
+		Logger:          logger,
+		Metrics:         registry,
+		TracerProvider:  tracerProvider,
+		HealthChecks:    healthChecks,
+		BuildInfo:       serverConfig.BuildInfo,
+		UserHooks:       userHooks,
+		RoleRepository:  db.NewDBRoleRepository(db.GetConnection()),
+		MeService:       meService,
+		WebappFS:        serverConfig.WebappFS,
+		WebappPath:      serverConfig.WebappPath,
+		ShutdownTimeout: appConfig.Server.ShutdownTimeout,
+	}
+
+	// Registered first so the connection pool is closed last, after every other hook
+	server.RegisterShutdownHook("database", func(ctx context.Context) error {
+		return db.Close()
+	})
+	// Flushes the spans of the last requests before the database is closed
+	server.RegisterShutdownHook("tracing", shutdownTracing)
+
+	if appConfig.Metrics.Enabled {
+		server.configureMetrics(appConfig.Metrics)
 	}
 
 	// Configure webapp serving first (will be overridden by more specific routes)
@@ -93,14 +216,48 @@ func NewServer(serverConfig *ServerConfig) (*Server, error) {
 	return server, nil
 }
 
-// NewServerFromEmbedFS creates and configures a new server instance with embedded webapp files
-func NewServerFromEmbedFS(host string, port int, webappFS embed.FS, webappPath string) (*Server, error) {
-	// Initialize the database connection
-	db.Init()
+// withRequestDeadline bounds the request context by the write timeout. net/http cancels the context
+// when the client disconnects but not when WriteTimeout expires, so without it database queries and
+// gateway calls would keep running for a response that can no longer be written.
+func withRequestDeadline(next http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// newHealthChecks registers the readiness checks of the database, its schema, the gateway and,
+// for SQLite databases, the free disk space
+func newHealthChecks(appConfig *config.AppConfig, sqlDB *sql.DB, taronjaClient *client.ClientWithResponses) (*health.Registry, error) {
+	target, err := appConfig.Database.Target()
+	if err != nil {
+		return nil, err
+	}
+
+	schemaChecker, err := health.SchemaChecker(db.GetConnection())
+	if err != nil {
+		return nil, err
+	}
+
+	healthChecks := health.NewRegistry(appConfig.Health.CheckTimeout, appConfig.Health.CacheTTL)
+	healthChecks.Register("database", true, health.DatabaseChecker(sqlDB))
+	healthChecks.Register("schema", true, schemaChecker)
+	// Only /api/me needs the gateway, the other endpoints keep working while it is down
+	healthChecks.Register("gateway", false, health.GatewayChecker(taronjaClient))
+	file := target.SQLiteFile()
+	if file != "" && appConfig.Health.MinFreeDiskMB > 0 {
+		healthChecks.Register("disk", true, health.DiskSpaceChecker(file, uint64(appConfig.Health.MinFreeDiskMB)<<20))
+	}
+	return healthChecks, nil
+}
 
+// NewServerFromEmbedFS creates and configures a new server instance with embedded webapp files
+func NewServerFromEmbedFS(appConfig *config.AppConfig, logger *slog.Logger, info buildinfo.Info, webappFS embed.FS, webappPath string) (*Server, error) {
 	serverConfig := &ServerConfig{
-		Host:       host,
-		Port:       port,
+		AppConfig:  appConfig,
+		Logger:     logger,
+		BuildInfo:  info,
 		WebappFS:   webappFS,
 		WebappPath: webappPath,
 	}
@@ -108,40 +265,71 @@ func NewServerFromEmbedFS(host string, port int, webappFS embed.FS, webappPath s
 	return NewServer(serverConfig)
 }
 
+// configureMetrics serves the metrics on /metrics of the main port, or on a separate
+// listener that is started with the server and shut down after it
+func (s *Server) configureMetrics(cfg config.MetricsConfig) {
+	if cfg.Addr == "" {
+		// The gateway forwards every path of the main port, /metrics included
+		s.Logger.Info("Serving /metrics on the main port, set metrics.addr to keep it off the gateway")
+		s.Mux.Handle("/metrics", s.Metrics.Handler())
+		return
+	}
+
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", s.Metrics.Handler())
+	s.MetricsServer = &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           metricsMux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ErrorLog:          slog.NewLogLogger(s.Logger.Handler(), slog.LevelError),
+	}
+	s.RegisterShutdownHook("metrics", func(ctx context.Context) error {
+		return s.MetricsServer.Shutdown(ctx)
+	})
+}
+
 // configureOpenAPIRoutes sets up the OpenAPI endpoints
 func (s *Server) configureOpenAPIRoutes() error {
-	log.Printf("Registering OpenAPI routes...")
+	s.Logger.Debug("Registering OpenAPI routes")
 
 	// Create the strict API server
-	strictApiServer := handlers.NewStrictApiServer()
+	strictApiServer := handlers.NewStrictApiServer(s.UserHooks, s.MeService, s.HealthChecks, s.BuildInfo)
+
+	// Authorization policies are declared on the operations of the OpenAPI spec
+	spec, err := api.GetSwagger()
+	if err != nil {
+		return fmt.Errorf("error loading OpenAPI spec: %w", err)
+	}
+	policies, err := services.PoliciesFromSpec(spec)
+	if err != nil {
+		return fmt.Errorf("error reading authorization policies: %w", err)
+	}
+	authorizer := services.NewAuthorizer(s.RoleRepository, policies)
 
-	// Configure strict handler options with simple error handling
+	// Domain errors returned by the handlers are mapped to application/problem+json responses
 	strictHandlerOptions := api.StrictHTTPServerOptions{
-		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
-			log.Printf("Request error: %v", err)
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		},
-		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
-			log.Printf("Response error: %v", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		},
+		RequestErrorHandlerFunc:  handlers.RequestErrorHandler,
+		ResponseErrorHandlerFunc: handlers.ResponseErrorHandler,
 	}
 
 	// Create the standard API server without middleware
 	standardApiServer := api.NewStrictHandlerWithOptions(
 		strictApiServer,
-		// Add middlewares for all endpoints
+		// Add middlewares for all endpoints, the last one runs first
 		[]api.StrictMiddlewareFunc{
+			authorizer.StrictMiddleware,
+			services.NewUserProvisioner(s.UserHooks).StrictMiddleware,
+			tracing.StrictMiddleware,
 			session.StrictInjectHTTPRequestMiddleware,
-			session.StrictCORSMiddleware,
 		},
 		strictHandlerOptions,
 	)
 
 	// Create the OpenAPI handler
 	openApiHandler := api.HandlerWithOptions(standardApiServer, api.StdHTTPServerOptions{
-		BaseURL:     "",
-		Middlewares: []api.MiddlewareFunc{}, // No additional middlewares
+		BaseURL:          "",
+		Middlewares:      []api.MiddlewareFunc{}, // No additional middlewares
+		ErrorHandlerFunc: handlers.ParameterErrorHandler,
 	})
 
 	// Register the API routes as defined in OpenAPI spec, "/api/" means to handle all routes with that prefix
@@ -152,13 +340,12 @@ func (s *Server) configureOpenAPIRoutes() error {
 
 // configureWebappRoutes sets up the webapp SPA serving
 func (s *Server) configureWebappRoutes() error {
-	log.Printf("Configuring webapp routes...")
+	s.Logger.Debug("Configuring webapp routes")
 
 	// Try to serve webapp from embedded FS
 	webappFS, err := fs.Sub(s.WebappFS, s.WebappPath)
 	if err != nil {
-		log.Printf("Warning: Could not access embedded webapp files: %v", err)
-		log.Println("Trying to serve webapp from filesystem...")
+		s.Logger.Warn("Cannot access embedded webapp files, trying the filesystem", "error", err)
 
 		// Fallback to serving from filesystem during development
 		s.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -172,8 +359,14 @@ func (s *Server) configureWebappRoutes() error {
 			s.serveSPAFromFileSystem(w, r, s.WebappPath)
 		})
 
-		log.Println("Serving webapp SPA from filesystem")
+		s.Logger.Info("Serving webapp SPA from filesystem", "path", s.WebappPath)
 	} else {
+		// The embedded files never change while the binary runs, their ETags are computed once
+		etags, err := webappETags(webappFS)
+		if err != nil {
+			s.Logger.Warn("Cannot compute the ETags of the embedded webapp files", "error", err)
+		}
+
 		// Serve the webapp files from embedded FS
 		s.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 			// Skip API routes - they should be handled by the API handler
@@ -183,10 +376,10 @@ func (s *Server) configureWebappRoutes() error {
 				return
 			}
 
-			s.serveSPAFromEmbeddedFS(w, r, webappFS)
+			s.serveSPAFromEmbeddedFS(w, r, webappFS, etags)
 		})
 
-		log.Println("Serving webapp SPA from embedded files")
+		s.Logger.Info("Serving webapp SPA from embedded files", "files", len(etags))
 	}
 
 	return nil
@@ -202,19 +395,32 @@ func (s *Server) serveSPAFromFileSystem(w http.ResponseWriter, r *http.Request,
 
 	// Try to serve the requested file
 	filePath := rootDir + path
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
 		// File doesn't exist, serve index.html for SPA routing
+		setCacheHeaders(w.Header(), "index.html", nil)
+		if compress.ServePrecompressed(w, r, os.DirFS(rootDir), "index.html") {
+			return
+		}
 		http.ServeFile(w, r, rootDir+"/index.html")
 		return
 	}
 
-	// File exists, serve it
+	// File exists, serve it, precompressed when the build produced .br or .gz siblings.
+	// Files on disk have a modification time, http.ServeFile validates caches with Last-Modified.
+	if err == nil && !info.IsDir() {
+		setCacheHeaders(w.Header(), path[1:], nil)
+	}
+	if compress.ServePrecompressed(w, r, os.DirFS(rootDir), path[1:]) {
+		return
+	}
 	http.ServeFile(w, r, filePath)
 }
 
 // serveSPAFromEmbeddedFS serves files from embedded FS for SPA
-// If the file doesn't exist, it serves index.html for client-side routing
-func (s *Server) serveSPAFromEmbeddedFS(w http.ResponseWriter, r *http.Request, webappFS fs.FS) {
+// If the file doesn't exist, it serves index.html for client-side routing.
+// etags holds the ETag of every file, computed by webappETags.
+func (s *Server) serveSPAFromEmbeddedFS(w http.ResponseWriter, r *http.Request, webappFS fs.FS, etags map[string]string) {
 	path := r.URL.Path
 	if path == "/" {
 		path = "/index.html"
@@ -236,6 +442,11 @@ func (s *Server) serveSPAFromEmbeddedFS(w http.ResponseWriter, r *http.Request,
 		}
 		defer indexFile.Close()
 
+		setCacheHeaders(w.Header(), "index.html", etags)
+		if compress.ServePrecompressed(w, r, webappFS, "index.html") {
+			return
+		}
+
 		// Set appropriate content type for HTML
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
@@ -246,24 +457,146 @@ func (s *Server) serveSPAFromEmbeddedFS(w http.ResponseWriter, r *http.Request,
 			return
 		}
 
-		w.Write(indexContent)
+		// Embedded files have no modification time, the ETag validates the cached copy
+		http.ServeContent(w, r, "index.html", time.Time{}, bytes.NewReader(indexContent))
 		return
 	}
 	defer file.Close()
 
-	// File exists, serve it using http.FileServer
+	info, err := file.Stat()
+	if err == nil && !info.IsDir() {
+		setCacheHeaders(w.Header(), path, etags)
+	}
+	if compress.ServePrecompressed(w, r, webappFS, path) {
+		return
+	}
+
+	// File exists, serve it using http.FileServer, which answers 304 when the ETag matches
 	fileServer := http.FileServer(http.FS(webappFS))
 	fileServer.ServeHTTP(w, r)
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server.
+// It blocks until the server stops and returns http.ErrServerClosed after Shutdown.
 func (s *Server) Start() error {
-	log.Printf("Starting server on %s", s.HTTPServer.Addr)
+	if s.MetricsServer != nil {
+		go func() {
+			s.Logger.Info("Starting metrics server", "addr", s.MetricsServer.Addr)
+			err := s.MetricsServer.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.Logger.Error("Metrics server stopped", "error", err)
+			}
+		}()
+	}
+
+	s.Logger.Info("Starting server", "addr", s.HTTPServer.Addr)
 	return s.HTTPServer.ListenAndServe()
 }
 
-// Stop gracefully stops the HTTP server
+// Run starts the HTTP server and blocks until SIGINT or SIGTERM is received,
+// then shuts the server down gracefully.
+func (s *Server) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return s.RunContext(ctx)
+}
+
+// RunContext starts the HTTP server and blocks until ctx is done, then shuts
+// the server down gracefully. It returns early if the server fails to start.
+func (s *Server) RunContext(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Start()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		// The listener failed, still release resources held by the hooks
+		shutdownErr := s.runShutdownHooks(context.Background())
+		return errors.Join(err, shutdownErr)
+	case <-ctx.Done():
+		s.Logger.Info("Shutdown signal received, draining in-flight requests", "timeout", s.ShutdownTimeout)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+
+	err := s.Shutdown(shutdownCtx)
+
+	// Wait for ListenAndServe to return so the caller knows the listener is gone
+	startErr := <-errCh
+	if startErr != nil && !errors.Is(startErr, http.ErrServerClosed) {
+		err = errors.Join(err, startErr)
+	}
+	return err
+}
+
+// RegisterShutdownHook registers a hook to run on shutdown.
+// Hooks run in reverse registration order after in-flight requests have drained.
+func (s *Server) RegisterShutdownHook(name string, hook ShutdownHook) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+
+	s.shutdownHooks = append(s.shutdownHooks, namedShutdownHook{name: name, hook: hook})
+}
+
+// shutdownHookTimeout bounds each shutdown hook. Hooks do not share the deadline of the drain,
+// which has expired when requests did not finish in time.
+const shutdownHookTimeout = 10 * time.Second
+
+// Shutdown stops accepting new connections, waits for in-flight requests to
+// finish until ctx expires, and then runs the registered shutdown hooks.
+// All errors are collected and returned together.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	err := s.HTTPServer.Shutdown(ctx)
+	if err != nil {
+		s.Logger.Warn("HTTP server did not drain cleanly", "error", err)
+		errs = append(errs, fmt.Errorf("http server shutdown: %w", err))
+	}
+
+	err = s.runShutdownHooks(ctx)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// runShutdownHooks runs the registered hooks in reverse order, once each, every hook with
+// shutdownHookTimeout of its own whether or not ctx is done
+func (s *Server) runShutdownHooks(ctx context.Context) error {
+	s.hooksMu.Lock()
+	hooks := s.shutdownHooks
+	s.shutdownHooks = nil
+	s.hooksMu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		s.Logger.Info("Running shutdown hook", "hook", h.name)
+		hookCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownHookTimeout)
+		err := h.hook(hookCtx)
+		cancel()
+		if err != nil {
+			s.Logger.Warn("Shutdown hook failed", "hook", h.name, "error", err)
+			errs = append(errs, fmt.Errorf("shutdown hook %q: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stop gracefully stops the HTTP server, waiting up to ShutdownTimeout for in-flight requests
 func (s *Server) Stop() error {
-	log.Printf("Stopping server...")
-	return s.HTTPServer.Close()
+	s.Logger.Info("Stopping server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+
+	return s.Shutdown(ctx)
 }
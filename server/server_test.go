@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer creates a Server around a handler without touching the database
+func newTestServer(handler http.Handler) *Server {
+	return &Server{
+		HTTPServer:      &http.Server{Addr: "127.0.0.1:0", Handler: handler},
+		Logger:          slog.New(slog.DiscardHandler),
+		ShutdownTimeout: 5 * time.Second,
+	}
+}
+
+func TestShutdownHooks(t *testing.T) {
+	t.Run("RunInReverseOrder", func(t *testing.T) {
+		s := newTestServer(http.NewServeMux())
+
+		var order []string
+		for _, name := range []string{"first", "second", "third"} {
+			s.RegisterShutdownHook(name, func(ctx context.Context) error {
+				order = append(order, name)
+				return nil
+			})
+		}
+
+		err := s.Shutdown(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"third", "second", "first"}, order)
+	})
+
+	t.Run("ErrorsAreCollected", func(t *testing.T) {
+		s := newTestServer(http.NewServeMux())
+
+		ran := false
+		s.RegisterShutdownHook("ok", func(ctx context.Context) error {
+			ran = true
+			return nil
+		})
+		s.RegisterShutdownHook("failing", func(ctx context.Context) error {
+			return errors.New("boom")
+		})
+
+		err := s.Shutdown(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failing")
+		assert.Contains(t, err.Error(), "boom")
+		assert.True(t, ran, "Hooks after a failing one should still run")
+	})
+
+	t.Run("RunOnlyOnce", func(t *testing.T) {
+		s := newTestServer(http.NewServeMux())
+
+		calls := 0
+		s.RegisterShutdownHook("counter", func(ctx context.Context) error {
+			calls++
+			return nil
+		})
+
+		assert.NoError(t, s.Shutdown(context.Background()))
+		assert.NoError(t, s.Shutdown(context.Background()))
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+	s := newTestServer(handler)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go s.HTTPServer.Serve(listener)
+
+	type result struct {
+		body string
+		err  error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		if err != nil {
+			resultCh <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		resultCh <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	err = s.Shutdown(context.Background())
+	assert.NoError(t, err)
+
+	res := <-resultCh
+	assert.NoError(t, res.err, "In-flight request should complete during shutdown")
+	assert.Equal(t, "done", res.body)
+}
+
+func TestShutdownDeadlineExceeded(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	s := newTestServer(handler)
+	defer close(release)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go s.HTTPServer.Serve(listener)
+	go http.Get("http://" + listener.Addr().String() + "/stuck")
+
+	hookRan := false
+	s.RegisterShutdownHook("cleanup", func(ctx context.Context) error {
+		hookRan = true
+		assert.NoError(t, ctx.Err(), "Hooks get a context of their own")
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		return nil
+	})
+
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = s.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, hookRan, "Hooks should run even when draining times out")
+}
+
+func TestRunContext(t *testing.T) {
+	s := newTestServer(http.NewServeMux())
+
+	hookRan := make(chan struct{})
+	s.RegisterShutdownHook("signal", func(ctx context.Context) error {
+		close(hookRan)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.RunContext(ctx)
+	}()
+
+	// Simulate the shutdown signal
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext did not return after the context was cancelled")
+	}
+
+	select {
+	case <-hookRan:
+	default:
+		t.Fatal("Shutdown hook was not run")
+	}
+}
+
+func TestRequestDeadline(t *testing.T) {
+	var handlerErr error
+	handler := withRequestDeadline(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Stands in for a slow query that honours the request context
+		<-r.Context().Done()
+		handlerErr = r.Context().Err()
+	}), 50*time.Millisecond)
+
+	start := time.Now()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.ErrorIs(t, handlerErr, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestNewServerFailureClosesDatabase(t *testing.T) {
+	appConfig := config.Default()
+	appConfig.Database.URL = "sqlite://" + filepath.Join(t.TempDir(), "server.db")
+	// Fails after the database was opened
+	appConfig.Session.TrustedProxies = []string{"not-an-ip"}
+
+	_, err := NewServer(&ServerConfig{AppConfig: appConfig, Logger: slog.New(slog.DiscardHandler)})
+	assert.Error(t, err)
+	assert.Panics(t, func() { db.GetConnection() }, "The connection is closed and released")
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// assetsDir holds the files Vite names after a hash of their content, a given URL never changes
+const assetsDir = "assets/"
+
+// Cache-Control values of the webapp files
+const (
+	cacheControlImmutable = "public, max-age=31536000, immutable"
+	// The browser may keep the file but must check it is current, index.html points to the latest assets
+	cacheControlRevalidate = "no-cache"
+)
+
+// webappETags computes the strong ETag of every file of fsys from its content, embedded files
+// having no modification time for the browser to revalidate against
+func webappETags(fsys fs.FS) (map[string]string, error) {
+	etags := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		etags[name] = `"` + hex.EncodeToString(sum[:12]) + `"`
+		return nil
+	})
+	return etags, err
+}
+
+// setCacheHeaders sets the Cache-Control header of the webapp file name, and its ETag when known.
+// http.ServeContent answers 304 to the requests whose If-None-Match matches it.
+func setCacheHeaders(h http.Header, name string, etags map[string]string) {
+	if strings.HasPrefix(name, assetsDir) {
+		h.Set("Cache-Control", cacheControlImmutable)
+	} else {
+		h.Set("Cache-Control", cacheControlRevalidate)
+	}
+
+	etag, ok := etags[name]
+	if ok {
+		h.Set("ETag", etag)
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmaister/gots-template/compress"
+	"github.com/jmaister/gots-template/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebappETags(t *testing.T) {
+	webappFS := fstest.MapFS{
+		"index.html":          {Data: []byte("<html></html>")},
+		"assets/index-a1.js":  {Data: []byte("console.log(1)")},
+		"assets/index-b2.js":  {Data: []byte("console.log(1)")},
+		"assets/index-c3.css": {Data: []byte("body{}")},
+	}
+	etags, err := webappETags(webappFS)
+	assert.NoError(t, err)
+	assert.Len(t, etags, 4)
+	assert.Regexp(t, `^"[0-9a-f]{24}"$`, etags["index.html"])
+	assert.Equal(t, etags["assets/index-a1.js"], etags["assets/index-b2.js"], "The ETag depends on the content only")
+	assert.NotEqual(t, etags["assets/index-a1.js"], etags["assets/index-c3.css"])
+}
+
+func TestServeSPAFromEmbeddedFS(t *testing.T) {
+	webappFS := fstest.MapFS{
+		"index.html":         {Data: []byte("<!DOCTYPE html><html><body>app</body></html>")},
+		"favicon.ico":        {Data: []byte("icon")},
+		"assets/index-a1.js": {Data: []byte("console.log('app')")},
+	}
+	etags, err := webappETags(webappFS)
+	assert.NoError(t, err)
+	s := newTestServer(nil)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.serveSPAFromEmbeddedFS(w, r, webappFS, etags)
+	})
+	get := func(handler http.Handler, path, ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	tests := []struct {
+		path         string
+		file         string
+		cacheControl string
+	}{
+		{"/assets/index-a1.js", "assets/index-a1.js", cacheControlImmutable},
+		{"/", "index.html", cacheControlRevalidate},
+		{"/users/42", "index.html", cacheControlRevalidate},
+		{"/favicon.ico", "favicon.ico", cacheControlRevalidate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := get(handler, tt.path, "")
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, string(webappFS[tt.file].Data), w.Body.String())
+			assert.Equal(t, tt.cacheControl, w.Header().Get("Cache-Control"))
+			etag := w.Header().Get("ETag")
+			assert.Equal(t, etags[tt.file], etag)
+			assert.Empty(t, w.Header().Get("Last-Modified"), "Embedded files have no modification time")
+
+			w = get(handler, tt.path, etag)
+			assert.Equal(t, http.StatusNotModified, w.Code)
+			assert.Zero(t, w.Body.Len())
+
+			w = get(handler, tt.path, `"outdated"`)
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+
+	t.Run("Compressed", func(t *testing.T) {
+		webappFS["index.html"] = &fstest.MapFile{Data: []byte("<!DOCTYPE html><html><body>" + strings.Repeat("<p>app</p>", 200) + "</body></html>")}
+		etags, err := webappETags(webappFS)
+		assert.NoError(t, err)
+		compressed := compress.NewMiddleware(config.CompressionConfig{Enabled: true, MinSize: 1024})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.serveSPAFromEmbeddedFS(w, r, webappFS, etags)
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		compressed.ServeHTTP(w, r)
+		assert.Equal(t, compress.EncodingGzip, w.Header().Get("Content-Encoding"))
+		etag := w.Header().Get("ETag")
+		assert.Equal(t, "W/"+etags["index.html"], etag)
+
+		// Browsers send back the weak ETag, which still matches
+		r.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		compressed.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+}
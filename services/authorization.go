@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jmaister/gots-template/db"
+	"github.com/jmaister/gots-template/session"
+	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
+)
+
+// OpenAPI operation extensions that declare the policy of an operation
+const (
+	ExtensionRequiredRole        = "x-required-role"
+	ExtensionRequiredPermissions = "x-required-permissions"
+)
+
+// Policy is what a user needs to call an operation: the role, if set, and every permission.
+// An empty policy makes the operation public.
+type Policy struct {
+	Role        string
+	Permissions []string
+}
+
+// IsPublic reports whether the policy lets anonymous requests through
+func (p Policy) IsPublic() bool {
+	return p.Role == "" && len(p.Permissions) == 0
+}
+
+// PoliciesFromSpec reads the x-required-role and x-required-permissions extensions of every
+// operation, keyed by the operation name the strict middlewares receive (the operationId
+// starting with an upper case letter, e.g. listUsers is ListUsers)
+func PoliciesFromSpec(spec *openapi3.T) (map[string]Policy, error) {
+	policies := make(map[string]Policy)
+	for path, pathItem := range spec.Paths.Map() {
+		for method, operation := range pathItem.Operations() {
+			if operation.OperationID == "" {
+				continue
+			}
+			policy, err := operationPolicy(operation)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			if !policy.IsPublic() {
+				policies[strictOperationName(operation.OperationID)] = policy
+			}
+		}
+	}
+	return policies, nil
+}
+
+func operationPolicy(operation *openapi3.Operation) (Policy, error) {
+	policy := Policy{}
+
+	if value, ok := operation.Extensions[ExtensionRequiredRole]; ok {
+		role, isString := value.(string)
+		if !isString {
+			return Policy{}, fmt.Errorf("%s must be a string", ExtensionRequiredRole)
+		}
+		policy.Role = role
+	}
+
+	if value, ok := operation.Extensions[ExtensionRequiredPermissions]; ok {
+		items, isList := value.([]interface{})
+		if !isList {
+			return Policy{}, fmt.Errorf("%s must be a list of strings", ExtensionRequiredPermissions)
+		}
+		for _, item := range items {
+			permission, isString := item.(string)
+			if !isString {
+				return Policy{}, fmt.Errorf("%s must be a list of strings", ExtensionRequiredPermissions)
+			}
+			policy.Permissions = append(policy.Permissions, permission)
+		}
+	}
+
+	return policy, nil
+}
+
+// strictOperationName converts an operationId to the name generated by oapi-codegen
+func strictOperationName(operationID string) string {
+	runes := []rune(operationID)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// Authorizer enforces the policies of the operations. Gateway admins (session.Session.IsAdmin)
+// pass every policy, other users need the roles and permissions assigned to their local user.
+type Authorizer struct {
+	roles    db.RoleRepository
+	policies map[string]Policy
+}
+
+func NewAuthorizer(roles db.RoleRepository, policies map[string]Policy) *Authorizer {
+	return &Authorizer{
+		roles:    roles,
+		policies: policies,
+	}
+}
+
+// Authorize checks a policy for a request. It returns session.ErrUnauthenticated for anonymous
+// requests to protected operations and session.ErrForbidden when the user lacks a role or permission.
+func (a *Authorizer) Authorize(ctx context.Context, reqCtx *session.RequestContext, policy Policy) error {
+	if policy.IsPublic() {
+		return nil
+	}
+	if reqCtx == nil || !reqCtx.IsAuthenticated {
+		return session.ErrUnauthenticated
+	}
+	if policy.Role == db.RoleAuthenticated && len(policy.Permissions) == 0 {
+		// Satisfied by the session alone, the roles are not read
+		return nil
+	}
+
+	userData, err := reqCtx.GetUserData()
+	if err == nil && userData.IsAdmin {
+		return nil
+	}
+
+	roles, err := a.userRoles(ctx, reqCtx)
+	if err != nil {
+		return err
+	}
+
+	if policy.Role != "" && policy.Role != db.RoleAuthenticated {
+		hasRole := slices.ContainsFunc(roles, func(role *db.Role) bool {
+			return role.Name == policy.Role
+		})
+		if !hasRole {
+			return fmt.Errorf("%w: requires role %s", session.ErrForbidden, policy.Role)
+		}
+	}
+
+	for _, permission := range policy.Permissions {
+		granted := slices.ContainsFunc(roles, func(role *db.Role) bool {
+			return slices.Contains(role.PermissionNames(), permission)
+		})
+		if !granted {
+			return fmt.Errorf("%w: requires permission %s", session.ErrForbidden, permission)
+		}
+	}
+	return nil
+}
+
+// userRoles returns the roles of the local user, none when the user could not be provisioned
+func (a *Authorizer) userRoles(ctx context.Context, reqCtx *session.RequestContext) ([]*db.Role, error) {
+	if reqCtx.LocalUser == nil {
+		return nil, nil
+	}
+	return a.roles.GetUserRoles(ctx, reqCtx.LocalUser.ID)
+}
+
+// StrictMiddleware enforces the policy of the operation before calling the handler.
+// It reads the local user, so it must run inside the UserProvisioner middleware.
+func (a *Authorizer) StrictMiddleware(next strictnethttp.StrictHTTPHandlerFunc, operationName string) strictnethttp.StrictHTTPHandlerFunc {
+	policy := a.policies[operationName]
+	if policy.IsPublic() {
+		return next
+	}
+
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		reqCtx, _ := session.GetRequestContext(ctx)
+		err := a.Authorize(ctx, reqCtx, policy)
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, w, r, request)
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jmaister/gots-template/api"
+	"github.com/jmaister/gots-template/db"
+	"github.com/jmaister/gots-template/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoliciesFromSpec(t *testing.T) {
+	spec, err := api.GetSwagger()
+	assert.NoError(t, err)
+
+	policies, err := PoliciesFromSpec(spec)
+	assert.NoError(t, err)
+
+	assert.Equal(t, Policy{Permissions: []string{"users:read"}}, policies["ListUsers"])
+	assert.Equal(t, Policy{Permissions: []string{"users:write"}}, policies["DeleteUser"])
+	assert.Equal(t, Policy{Role: db.RoleAuthenticated}, policies["GetMe"])
+	assert.NotContains(t, policies, "HealthCheck", "Operations without extensions are public")
+}
+
+func TestPoliciesFromSpecInvalid(t *testing.T) {
+	spec := &openapi3.T{Paths: openapi3.NewPaths()}
+	operation := openapi3.NewOperation()
+	operation.OperationID = "listThings"
+	operation.Extensions = map[string]interface{}{ExtensionRequiredPermissions: "things:read"}
+	spec.Paths.Set("/api/things", &openapi3.PathItem{Get: operation})
+
+	_, err := PoliciesFromSpec(spec)
+	assert.ErrorContains(t, err, "GET /api/things")
+}
+
+// authorizationContext returns the request context of an authenticated gateway user
+func authorizationContext(localUser *db.User, isAdmin bool) *session.RequestContext {
+	reqCtx := &session.RequestContext{
+		UserID:          "gw-1",
+		IsAuthenticated: true,
+		Session:         &session.Session{UserID: "gw-1", IsAdmin: isAdmin},
+		LocalUser:       localUser,
+	}
+	return reqCtx
+}
+
+func TestAuthorize(t *testing.T) {
+	ctx := context.Background()
+	roles := db.NewMemoryRoleRepository()
+	err := roles.CreateRole(ctx, &db.Role{Name: "viewer", Permissions: []db.RolePermission{{Permission: "users:read"}}})
+	assert.NoError(t, err)
+	err = roles.AssignRole(ctx, 1, "viewer")
+	assert.NoError(t, err)
+
+	authorizer := NewAuthorizer(roles, nil)
+	viewer := authorizationContext(&db.User{ID: 1}, false)
+	nobody := authorizationContext(&db.User{ID: 2}, false)
+	admin := authorizationContext(&db.User{ID: 3}, true)
+	anonymous := &session.RequestContext{}
+
+	tests := []struct {
+		name    string
+		reqCtx  *session.RequestContext
+		policy  Policy
+		wantErr error
+	}{
+		{"PublicAnonymous", anonymous, Policy{}, nil},
+		{"Anonymous", anonymous, Policy{Role: db.RoleAuthenticated}, session.ErrUnauthenticated},
+		{"MissingContext", nil, Policy{Permissions: []string{"users:read"}}, session.ErrUnauthenticated},
+		{"Authenticated", nobody, Policy{Role: db.RoleAuthenticated}, nil},
+		{"Permission", viewer, Policy{Permissions: []string{"users:read"}}, nil},
+		{"MissingPermission", viewer, Policy{Permissions: []string{"users:read", "users:write"}}, session.ErrForbidden},
+		{"Role", viewer, Policy{Role: "viewer"}, nil},
+		{"MissingRole", nobody, Policy{Role: "viewer"}, session.ErrForbidden},
+		{"AdminRole", viewer, Policy{Role: db.RoleAdmin}, session.ErrForbidden},
+		{"GatewayAdmin", admin, Policy{Role: db.RoleAdmin, Permissions: []string{"users:write"}}, nil},
+		{"NotProvisioned", authorizationContext(nil, false), Policy{Permissions: []string{"users:read"}}, session.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizer.Authorize(ctx, tt.reqCtx, tt.policy)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeAuthenticatedOnly(t *testing.T) {
+	// Without a role repository, any role lookup would panic
+	authorizer := NewAuthorizer(nil, nil)
+	err := authorizer.Authorize(context.Background(), authorizationContext(&db.User{ID: 1}, false), Policy{Role: db.RoleAuthenticated})
+	assert.NoError(t, err)
+}
+
+func TestAuthorizerStrictMiddleware(t *testing.T) {
+	policies := map[string]Policy{"ListUsers": {Permissions: []string{"users:read"}}}
+	authorizer := NewAuthorizer(db.NewMemoryRoleRepository(), policies)
+
+	called := false
+	next := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		called = true
+		return "ok", nil
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	ctx := context.Background()
+
+	// Public operations are not wrapped
+	response, err := authorizer.StrictMiddleware(next, "HealthCheck")(ctx, httptest.NewRecorder(), r, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", response)
+
+	// Without a RequestContext the request is anonymous
+	called = false
+	_, err = authorizer.StrictMiddleware(next, "ListUsers")(ctx, httptest.NewRecorder(), r, nil)
+	assert.ErrorIs(t, err, session.ErrUnauthenticated)
+	assert.False(t, called, "The handler must not run")
+}
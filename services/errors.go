@@ -0,0 +1,6 @@
+package services
+
+import "errors"
+
+// ErrUpstream is returned when Taronja Gateway cannot be reached or answers with an unexpected status
+var ErrUpstream = errors.New("upstream service error")
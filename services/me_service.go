@@ -2,31 +2,67 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
 
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/metrics"
 	"github.com/jmaister/gots-template/session"
+	"github.com/jmaister/gots-template/tracing"
 	client "github.com/jmaister/taronja-gateway-clients/go"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type MeServiceInterface interface {
-	GetMe() (*client.GetCurrentUserResponse, error)
+	GetMe(ctx context.Context) (*client.GetCurrentUserResponse, error)
 }
 
 type MeService struct {
 	client     *client.ClientWithResponses
 	adminToken string // Token for server-level operations (admin)
+	metrics    *metrics.GatewayMetrics
 }
 
-func NewMeService(taronjaClient *client.ClientWithResponses, adminToken string) *MeService {
+// NewMeService creates the service. Gateway calls are recorded in gatewayMetrics, which may be nil.
+func NewMeService(taronjaClient *client.ClientWithResponses, gatewayConfig config.GatewayConfig, gatewayMetrics *metrics.GatewayMetrics) *MeService {
 	return &MeService{
 		client:     taronjaClient,
-		adminToken: adminToken,
+		adminToken: gatewayConfig.AdminToken,
+		metrics:    gatewayMetrics,
 	}
 }
 
 func (s *MeService) GetMe(ctx context.Context) (*client.GetCurrentUserResponse, error) {
-	resp, err := s.client.GetCurrentUserWithResponse(ctx, session.ForwardAuthorizationCookie)
+	ctx, span := tracing.Start(ctx, "GetCurrentUser", trace.WithSpanKind(trace.SpanKindClient))
+	start := time.Now()
+	resp, err := s.getCurrentUser(ctx)
+	s.metrics.Observe(ctx, "GetCurrentUser", start, err)
+	tracing.End(span, err)
+	return resp, err
+}
+
+func (s *MeService) getCurrentUser(ctx context.Context) (*client.GetCurrentUserResponse, error) {
+	resp, err := s.client.GetCurrentUserWithResponse(ctx, session.ForwardAuthorizationCookie, session.ForwardRequestID, tracing.PropagateTraceContext)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, session.ErrUnauthenticated) || ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: get current user: %w", ErrUpstream, err)
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode()))
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusUnauthorized:
+		return nil, session.ErrUnauthenticated
+	default:
+		return nil, fmt.Errorf("%w: get current user returned %s", ErrUpstream, resp.Status())
 	}
-	return resp, nil
 }
+
+// Ensure MeService implements MeServiceInterface
+var _ MeServiceInterface = (*MeService)(nil)
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/jmaister/gots-template/db"
+	"github.com/jmaister/gots-template/session"
+	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
+)
+
+// syntheticEmailDomain is the domain of the placeholder emails of provisioned users whose session
+// has no email, or whose email belongs to another local user. .invalid is a reserved top level
+// domain, so these addresses never receive mail.
+const syntheticEmailDomain = "gateway.invalid"
+
+// UserProvisioner keeps a local db.User for every user authenticated by Taronja Gateway,
+// keyed by the gateway user ID (db.User.ExternalID)
+type UserProvisioner struct {
+	repository db.UserRepository
+	// conflicts holds the conflicts already logged, so each one is logged once and not on every request
+	conflicts sync.Map
+}
+
+func NewUserProvisioner(repository db.UserRepository) *UserProvisioner {
+	return &UserProvisioner{
+		repository: repository,
+	}
+}
+
+// Provision returns the local user of a gateway user. It is created on the first request,
+// and its email, username and provider follow later changes made in the gateway.
+// The session has no display name, so Name starts as the username and is then left to the application.
+//
+// Usernames and emails are unique. A username another local user has is suffixed with the provider,
+// then with the gateway user ID. A taken or missing email is replaced with a placeholder: the gateway
+// user is never merged into the local user with the same email, the session does not prove who owns it.
+func (p *UserProvisioner) Provision(ctx context.Context, externalID string, userData session.Session) (*db.User, error) {
+	user, err := p.repository.GetByExternalID(ctx, externalID)
+	if errors.Is(err, db.ErrNotFound) {
+		return p.create(ctx, externalID, userData)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.sync(ctx, user, userData)
+}
+
+func (p *UserProvisioner) create(ctx context.Context, externalID string, userData session.Session) (*db.User, error) {
+	var err error
+	// Retried once, a concurrent request may take the username or email in between
+	for attempt := 0; attempt < 2; attempt++ {
+		var user *db.User
+		user, err = p.newUser(ctx, externalID, userData)
+		if err != nil {
+			return nil, err
+		}
+
+		err = p.repository.Create(ctx, user)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, db.ErrConflict) {
+			return nil, err
+		}
+
+		// Concurrent first requests of the same user: the other one created it
+		existing, getErr := p.repository.GetByExternalID(ctx, externalID)
+		if getErr == nil {
+			return existing, nil
+		}
+	}
+	return nil, err
+}
+
+// newUser returns the local user of a gateway user, with a username and an email no other user has
+func (p *UserProvisioner) newUser(ctx context.Context, externalID string, userData session.Session) (*db.User, error) {
+	base := userData.Username
+	if base == "" {
+		base = externalID
+	}
+	candidates := []string{base}
+	if userData.Provider != "" {
+		candidates = append(candidates, base+"-"+userData.Provider)
+	}
+	if base != externalID {
+		candidates = append(candidates, base+"-"+externalID)
+	}
+
+	username := ""
+	for _, candidate := range candidates {
+		taken, err := p.takenByOther(ctx, 0, candidate, p.repository.GetByUsername)
+		if err != nil {
+			return nil, err
+		}
+		if !taken {
+			username = candidate
+			break
+		}
+	}
+	if username == "" {
+		return nil, fmt.Errorf("%w: no free username for gateway user %s", db.ErrConflict, externalID)
+	}
+
+	email := userData.Email
+	if email == "" {
+		email = syntheticEmail(externalID)
+	} else {
+		taken, err := p.takenByOther(ctx, 0, email, p.repository.GetByEmail)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			p.logConflict(ctx, externalID, "email", email)
+			email = syntheticEmail(externalID)
+		}
+	}
+
+	return &db.User{
+		Email:      email,
+		Username:   username,
+		Name:       username,
+		ExternalID: &externalID,
+		Provider:   userData.Provider,
+	}, nil
+}
+
+// sync applies the changes made in the gateway. A username or email another local user has is not
+// applied, the user keeps the previous one until it is free.
+func (p *UserProvisioner) sync(ctx context.Context, user *db.User, userData session.Session) (*db.User, error) {
+	externalID := ""
+	if user.ExternalID != nil {
+		externalID = *user.ExternalID
+	}
+	updated := *user
+	changed := false
+
+	if userData.Email != "" && userData.Email != user.Email {
+		taken, err := p.takenByOther(ctx, user.ID, userData.Email, p.repository.GetByEmail)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			p.logConflict(ctx, externalID, "email", userData.Email)
+		} else {
+			updated.Email = userData.Email
+			changed = true
+		}
+	}
+	if userData.Username != "" && userData.Username != user.Username {
+		taken, err := p.takenByOther(ctx, user.ID, userData.Username, p.repository.GetByUsername)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			p.logConflict(ctx, externalID, "username", userData.Username)
+		} else {
+			updated.Username = userData.Username
+			changed = true
+		}
+	}
+	if userData.Provider != "" && userData.Provider != user.Provider {
+		updated.Provider = userData.Provider
+		changed = true
+	}
+	if !changed {
+		return user, nil
+	}
+
+	err := p.repository.Update(ctx, &updated)
+	if errors.Is(err, db.ErrConflict) {
+		// Taken by a concurrent request since the checks above
+		p.logConflict(ctx, externalID, "email or username", updated.Email+" "+updated.Username)
+		return user, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// takenByOther reports whether a user other than id has the value, looked up with get
+func (p *UserProvisioner) takenByOther(ctx context.Context, id uint, value string, get func(context.Context, string) (*db.User, error)) (bool, error) {
+	other, err := get(ctx, value)
+	if errors.Is(err, db.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return other.ID != id, nil
+}
+
+// logConflict logs once that the value of a field sent by the gateway belongs to another local user.
+// The value is not logged, emails are personal data.
+func (p *UserProvisioner) logConflict(ctx context.Context, externalID string, field string, value string) {
+	_, logged := p.conflicts.LoadOrStore(externalID+"\x00"+field+"\x00"+value, struct{}{})
+	if logged {
+		return
+	}
+	session.Logger(ctx).WarnContext(ctx, "Gateway user has the "+field+" of another local user, it is not applied",
+		"external_id", externalID)
+}
+
+// syntheticEmail returns the placeholder email of a gateway user, unique like the gateway user ID
+func syntheticEmail(externalID string) string {
+	return "gateway-" + externalID + "@" + syntheticEmailDomain
+}
+
+// StrictMiddleware provisions the local user of authenticated requests and stores it in
+// session.RequestContext.LocalUser. It needs the RequestContext, so it must run inside
+// session.StrictInjectHTTPRequestMiddleware. Failures are logged and the request continues
+// without a local user, so endpoints that do not need it keep working.
+func (p *UserProvisioner) StrictMiddleware(next strictnethttp.StrictHTTPHandlerFunc, operationName string) strictnethttp.StrictHTTPHandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		reqCtx, err := session.GetRequestContext(ctx)
+		if err != nil || !reqCtx.IsAuthenticated {
+			return next(ctx, w, r, request)
+		}
+
+		userData, err := reqCtx.GetUserData()
+		if err != nil {
+			session.Logger(ctx).WarnContext(ctx, "Cannot provision local user", "error", err)
+			return next(ctx, w, r, request)
+		}
+
+		user, err := p.Provision(ctx, reqCtx.UserID, userData)
+		if err != nil {
+			session.Logger(ctx).WarnContext(ctx, "Cannot provision local user", "error", err)
+			return next(ctx, w, r, request)
+		}
+
+		reqCtx.LocalUser = user
+		return next(ctx, w, r, request)
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jmaister/gots-template/db"
+	"github.com/jmaister/gots-template/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvision(t *testing.T) {
+	ctx := context.Background()
+	repo := db.NewMemoryUserRepository()
+	provisioner := NewUserProvisioner(repo)
+	userData := session.Session{UserID: "gw-1", Username: "jane", Email: "jane@example.com", Provider: "google"}
+
+	t.Run("CreatedOnFirstRequest", func(t *testing.T) {
+		user, err := provisioner.Provision(ctx, "gw-1", userData)
+		assert.NoError(t, err)
+		assert.NotZero(t, user.ID)
+		assert.Equal(t, "gw-1", *user.ExternalID)
+		assert.Equal(t, "jane", user.Username)
+		assert.Equal(t, "jane", user.Name)
+		assert.Equal(t, "google", user.Provider)
+	})
+
+	t.Run("ReusedAfterwards", func(t *testing.T) {
+		first, err := provisioner.Provision(ctx, "gw-1", userData)
+		assert.NoError(t, err)
+		second, err := provisioner.Provision(ctx, "gw-1", userData)
+		assert.NoError(t, err)
+		assert.Equal(t, first.ID, second.ID)
+
+		users, err := repo.GetAll(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+	})
+
+	t.Run("SyncsChanges", func(t *testing.T) {
+		changed := userData
+		changed.Email = "jane.doe@example.com"
+		user, err := provisioner.Provision(ctx, "gw-1", changed)
+		assert.NoError(t, err)
+		assert.Equal(t, "jane.doe@example.com", user.Email)
+
+		stored, err := repo.GetByExternalID(ctx, "gw-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "jane.doe@example.com", stored.Email)
+		assert.Equal(t, "jane", stored.Name, "Name is not managed by the gateway")
+	})
+
+	t.Run("UsernameTakenByLocalUser", func(t *testing.T) {
+		err := repo.Create(ctx, &db.User{Email: "bob@example.com", Username: "bob"})
+		assert.NoError(t, err)
+
+		user, err := provisioner.Provision(ctx, "gw-2", session.Session{Username: "bob", Email: "bob2@example.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, "bob-gw-2", user.Username)
+		assert.Equal(t, "bob2@example.com", user.Email)
+	})
+
+	t.Run("UsernameFromSecondProvider", func(t *testing.T) {
+		user, err := provisioner.Provision(ctx, "gw-3", session.Session{Username: "jane", Email: "jane@github.example", Provider: "github"})
+		assert.NoError(t, err)
+		assert.Equal(t, "jane-github", user.Username)
+
+		// Same username and provider again: the gateway user ID is unique
+		user, err = provisioner.Provision(ctx, "gw-4", session.Session{Username: "jane", Email: "jane2@github.example", Provider: "github"})
+		assert.NoError(t, err)
+		assert.Equal(t, "jane-gw-4", user.Username)
+	})
+
+	t.Run("EmailTakenByLocalUser", func(t *testing.T) {
+		user, err := provisioner.Provision(ctx, "gw-5", session.Session{Username: "robert", Email: "bob@example.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, "gateway-gw-5@"+syntheticEmailDomain, user.Email, "Never merged into the local user")
+
+		bob, err := repo.GetByUsername(ctx, "bob")
+		assert.NoError(t, err)
+		assert.Nil(t, bob.ExternalID)
+	})
+
+	t.Run("MissingEmail", func(t *testing.T) {
+		user, err := provisioner.Provision(ctx, "gw-6", session.Session{Username: "anon"})
+		assert.NoError(t, err)
+		assert.Equal(t, "gateway-gw-6@"+syntheticEmailDomain, user.Email)
+
+		// The real email replaces the placeholder once the gateway knows it
+		user, err = provisioner.Provision(ctx, "gw-6", session.Session{Username: "anon", Email: "anon@example.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, "anon@example.com", user.Email)
+	})
+}
+
+func TestProvisionSyncConflicts(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := session.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil)))
+	repo := db.NewMemoryUserRepository()
+	provisioner := NewUserProvisioner(repo)
+
+	err := repo.Create(ctx, &db.User{Email: "bob@example.com", Username: "bob"})
+	assert.NoError(t, err)
+	_, err = provisioner.Provision(ctx, "gw-1", session.Session{Username: "jane", Email: "jane@example.com"})
+	assert.NoError(t, err)
+
+	// Both changed on the gateway to the values of the local user, twice
+	changed := session.Session{Username: "bob", Email: "bob@example.com", Provider: "github"}
+	for i := 0; i < 2; i++ {
+		user, err := provisioner.Provision(ctx, "gw-1", changed)
+		assert.NoError(t, err)
+		assert.Equal(t, "jane@example.com", user.Email, "The previous email is kept")
+		assert.Equal(t, "jane", user.Username, "The previous username is kept")
+		assert.Equal(t, "github", user.Provider, "Other changes are applied")
+	}
+
+	assert.Equal(t, 1, strings.Count(logs.String(), "email of another local user"), "Logged once")
+	assert.Equal(t, 1, strings.Count(logs.String(), "username of another local user"), "Logged once")
+	assert.NotContains(t, logs.String(), "bob@example.com")
+}
+
+func TestProvisioningMiddleware(t *testing.T) {
+	repo := db.NewMemoryUserRepository()
+	provisioner := NewUserProvisioner(repo)
+
+	// Same order as the server: the RequestContext is injected before provisioning runs
+	run := func(r *http.Request) *session.RequestContext {
+		var reqCtx *session.RequestContext
+		next := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+			var err error
+			reqCtx, err = session.GetRequestContext(ctx)
+			assert.NoError(t, err)
+			return nil, nil
+		}
+		handler := session.StrictInjectHTTPRequestMiddleware(provisioner.StrictMiddleware(next, "test"), "test")
+		_, err := handler(context.Background(), httptest.NewRecorder(), r, nil)
+		assert.NoError(t, err)
+		return reqCtx
+	}
+
+	t.Run("Authenticated", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+		r.Header.Set(session.HeaderUserId, "gw-1")
+		r.Header.Set(session.HeaderUserData, `{"UserID":"gw-1","Username":"jane","Email":"jane@example.com","Provider":"github"}`)
+
+		reqCtx := run(r)
+		user, err := reqCtx.GetLocalUser()
+		assert.NoError(t, err)
+		assert.Equal(t, "jane", user.Username)
+		assert.Equal(t, "github", user.Provider)
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		reqCtx := run(httptest.NewRequest(http.MethodGet, "/api/health", nil))
+		_, err := reqCtx.GetLocalUser()
+		assert.ErrorIs(t, err, session.ErrUnauthenticated)
+	})
+
+	t.Run("ProvisioningFailureDoesNotFailTheRequest", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+		r.Header.Set(session.HeaderUserId, "gw-3")
+		r.Header.Set(session.HeaderUserData, `{"UserID":"gw-3","Username":"invalid","Email":"not-an-email"}`)
+
+		reqCtx := run(r)
+		assert.NotNil(t, reqCtx)
+		_, err := reqCtx.GetLocalUser()
+		assert.Error(t, err)
+	})
+}
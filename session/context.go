@@ -3,25 +3,36 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/jmaister/gots-template/db"
 	client "github.com/jmaister/taronja-gateway-clients/go"
 )
 
 const (
-	HeaderUserId   = "X-User-Id"
-	HeaderUserData = "X-User-Data"
-	CookieSession  = "tg_session_token"
+	HeaderUserId    = "X-User-Id"
+	HeaderUserData  = "X-User-Data"
+	HeaderRequestID = "X-Request-ID"
+	CookieSession   = "tg_session_token"
 )
 
+// ErrUnauthenticated is returned when the request carries no authenticated session
+var ErrUnauthenticated = errors.New("user not authenticated")
+
+// ErrForbidden is returned when the authenticated user is not allowed to perform the operation
+var ErrForbidden = errors.New("permission denied")
+
 // contextKey is a custom type for context keys to avoid collisions.
 type contextKey string
 
-const requestContextKey contextKey = "requestContext"
+const (
+	requestContextKey       contextKey = "requestContext"
+	requestContextHolderKey contextKey = "requestContextHolder"
+)
 
 // RequestContext holds all request-related data including the raw request and parsed session.
 // Session data is parsed lazily on first access to avoid unnecessary overhead.
@@ -32,7 +43,9 @@ type RequestContext struct {
 	Session         *Session // Parsed lazily on first GetUserData() call
 	IsAuthenticated bool
 	RequestID       string     // Request ID for tracing
+	Operation       string     // Name of the OpenAPI operation, e.g. GetUser
 	RequestTime     time.Time  // When the request started
+	LocalUser       *db.User   // Local user of the gateway user, set by the user provisioning middleware
 	mu              sync.Mutex // Protects Session field during lazy parsing
 }
 
@@ -86,11 +99,31 @@ func GetRequestContext(ctx context.Context) (*RequestContext, error) {
 	return reqCtx, nil
 }
 
+// requestContextHolder receives the RequestContext created by StrictInjectHTTPRequestMiddleware
+type requestContextHolder struct {
+	reqCtx *RequestContext
+}
+
+// TrackRequestContext lets a net/http middleware wrapping the router read the RequestContext created
+// inside it, e.g. to log the user and the operation. The returned function returns nil until
+// StrictInjectHTTPRequestMiddleware ran, and for requests that are not API operations.
+func TrackRequestContext(ctx context.Context) (context.Context, func() *RequestContext) {
+	// Middlewares tracking the same request share the holder
+	holder, ok := ctx.Value(requestContextHolderKey).(*requestContextHolder)
+	if !ok {
+		holder = &requestContextHolder{}
+		ctx = context.WithValue(ctx, requestContextHolderKey, holder)
+	}
+	return ctx, func() *RequestContext {
+		return holder.reqCtx
+	}
+}
+
 // GetUserId retrieves the user ID from the request context.
 // This is a simple lookup with no header parsing.
 func (rc *RequestContext) GetUserId() (string, error) {
 	if rc.UserID == "" {
-		return "", fmt.Errorf("user not authenticated")
+		return "", ErrUnauthenticated
 	}
 	return rc.UserID, nil
 }
@@ -110,12 +143,13 @@ func (rc *RequestContext) GetUserData() (Session, error) {
 
 	// Parse session data if not already done
 	if rc.UserDataRaw == "" {
-		return Session{}, fmt.Errorf("session data not available")
+		return Session{}, fmt.Errorf("session data not available: %w", ErrUnauthenticated)
 	}
 
 	var session Session
 	if err := json.Unmarshal([]byte(rc.UserDataRaw), &session); err != nil {
-		log.Printf("Error: Failed to unmarshal user data: %v", err)
+		ctx := rc.logContext()
+		Logger(ctx).ErrorContext(ctx, "Failed to unmarshal user data", "error", err)
 		return Session{}, fmt.Errorf("invalid session data: %w", err)
 	}
 
@@ -124,6 +158,26 @@ func (rc *RequestContext) GetUserData() (Session, error) {
 	return session, nil
 }
 
+// logContext returns the context of the raw request, to log with its logger and request ID
+func (rc *RequestContext) logContext() context.Context {
+	if rc.RawRequest == nil {
+		return context.Background()
+	}
+	return rc.RawRequest.Context()
+}
+
+// GetLocalUser returns the local db.User provisioned for the authenticated gateway user.
+// Use it to join application data to the current user.
+func (rc *RequestContext) GetLocalUser() (*db.User, error) {
+	if rc.LocalUser == nil {
+		if !rc.IsAuthenticated {
+			return nil, ErrUnauthenticated
+		}
+		return nil, fmt.Errorf("local user not provisioned")
+	}
+	return rc.LocalUser, nil
+}
+
 // GetRawRequest retrieves the raw HTTP request from the request context.
 // Use this when you need direct access to headers, cookies, or other request details.
 func (rc *RequestContext) GetRawRequest() (*http.Request, error) {
@@ -79,7 +79,7 @@ func TestRequestContext_GetUserId(t *testing.T) {
 		// Assert
 		assert.Error(t, err)
 		assert.Empty(t, userID)
-		assert.Contains(t, err.Error(), "user not authenticated")
+		assert.ErrorIs(t, err, ErrUnauthenticated)
 	})
 }
 
@@ -143,6 +143,7 @@ func TestRequestContext_GetUserData(t *testing.T) {
 		// Assert
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "session data not available")
+		assert.ErrorIs(t, err, ErrUnauthenticated)
 		assert.Empty(t, result.UserID)
 	})
 
@@ -0,0 +1,133 @@
+package session
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jmaister/gots-template/config"
+)
+
+// corsAllowedMethods are the methods allowed on cross-origin requests, every method used by the API
+const corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+
+// corsPolicy answers CORS preflight requests and adds the CORS headers of the configured origins
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          map[string]bool
+	wildcards        []corsWildcard
+	allowedHeaders   string
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+// corsWildcard matches the subdomains of an origin pattern like https://*.example.com
+type corsWildcard struct {
+	prefix string // https://
+	suffix string // .example.com
+}
+
+func (w corsWildcard) matches(origin string) bool {
+	return len(origin) > len(w.prefix)+len(w.suffix) &&
+		strings.HasPrefix(origin, w.prefix) &&
+		strings.HasSuffix(origin, w.suffix)
+}
+
+// NewCORSMiddleware returns a net/http middleware applying the CORS policy. It must wrap the router,
+// so preflight OPTIONS requests are answered even for routes that only register other methods.
+// With no allowed origins it adds no headers and passes every request through.
+func NewCORSMiddleware(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	policy := &corsPolicy{
+		origins:          make(map[string]bool),
+		allowedHeaders:   strings.Join(cfg.AllowedHeaders, ", "),
+		exposedHeaders:   strings.Join(cfg.ExposedHeaders, ", "),
+		allowCredentials: cfg.AllowCredentials,
+		maxAge:           strconv.Itoa(int(cfg.MaxAge.Seconds())),
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			policy.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			policy.wildcards = append(policy.wildcards, corsWildcard{prefix: prefix, suffix: suffix})
+		default:
+			policy.origins[origin] = true
+		}
+	}
+
+	return policy.middleware
+}
+
+func (p *corsPolicy) allowed(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, wildcard := range p.wildcards {
+		if wildcard.matches(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *corsPolicy) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		// The response depends on the Origin, caches must not share it between origins
+		w.Header().Add("Vary", "Origin")
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin == "" || !p.allowed(origin) {
+			if preflight {
+				// Without the CORS headers the browser blocks the actual request
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if p.anyOrigin {
+			// Configuration validation rejects * with credentials
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if p.allowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			if p.allowedHeaders != "" {
+				w.Header().Set("Access-Control-Allow-Headers", p.allowedHeaders)
+			}
+			w.Header().Set("Access-Control-Max-Age", p.maxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if p.exposedHeaders != "" {
+			w.Header().Set("Access-Control-Expose-Headers", p.exposedHeaders)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
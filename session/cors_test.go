@@ -0,0 +1,127 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// corsHandler returns the CORS middleware around a handler answering 200
+func corsHandler(cfg config.CORSConfig) http.Handler {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return NewCORSMiddleware(cfg)(next)
+}
+
+func corsRequest(method string, origin string) *http.Request {
+	r := httptest.NewRequest(method, "/api/users", nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	return r
+}
+
+func preflightRequest(origin string) *http.Request {
+	r := corsRequest(http.MethodOptions, origin)
+	r.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+	r.Header.Set("Access-Control-Request-Headers", "Content-Type")
+	return r
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	cfg := config.Default().CORS
+	cfg.AllowedOrigins = []string{"https://app.example.com", "https://*.example.org"}
+	cfg.AllowCredentials = true
+	handler := corsHandler(cfg)
+
+	t.Run("Preflight", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, preflightRequest("https://app.example.com"))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "PATCH")
+		assert.Equal(t, "Content-Type, Authorization, X-Request-ID", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, w.Header().Values("Vary"))
+	})
+
+	t.Run("ActualRequest", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, corsRequest(http.MethodGet, "https://app.example.com"))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Empty(t, w.Header().Get("Access-Control-Max-Age"))
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+	})
+
+	t.Run("WildcardSubdomain", func(t *testing.T) {
+		for origin, allowed := range map[string]bool{
+			"https://admin.example.org":      true,
+			"https://a.b.example.org":        true,
+			"https://ADMIN.example.org":      true,
+			"https://example.org":            false,
+			"http://admin.example.org":       false,
+			"https://admin.example.org.evil": false,
+			"https://evilexample.org":        false,
+		} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, preflightRequest(origin))
+			if allowed {
+				assert.Equal(t, origin, w.Header().Get("Access-Control-Allow-Origin"), origin)
+			} else {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), origin)
+			}
+		}
+	})
+
+	t.Run("DisallowedOrigin", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, preflightRequest("https://evil.com"))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		// Actual requests still reach the handler, the browser hides the response
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, corsRequest(http.MethodGet, "https://evil.com"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+	})
+
+	t.Run("SameOrigin", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, corsRequest(http.MethodGet, ""))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestCORSMiddlewareAnyOrigin(t *testing.T) {
+	handler := corsHandler(config.CORSConfig{AllowedOrigins: []string{"*"}, MaxAge: time.Hour})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, preflightRequest("https://anything.test"))
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORSMiddlewareDisabled(t *testing.T) {
+	handler := corsHandler(config.Default().CORS)
+
+	// Preflights reach the router, which answers them like any other unknown route
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, preflightRequest("https://app.example.com"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Vary"))
+}
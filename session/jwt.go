@@ -0,0 +1,154 @@
+package session
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// identityClaims are the claims of the X-User-Token JWT signed by the gateway
+type identityClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+	NotBefore int64  `json:"nbf"`
+	// UserDataSHA256 is the hex SHA-256 of the X-User-Data header, so the token also covers the session data
+	UserDataSHA256 string `json:"user_data_sha256"`
+}
+
+// jwtVerifier checks compact JWS tokens signed with HS256, RS256 or ES256
+type jwtVerifier struct {
+	secret    []byte
+	publicKey crypto.PublicKey
+}
+
+// loadPublicKey reads an RSA or P-256 ECDSA public key from a PEM file
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return k, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("%s: only P-256 ECDSA keys are supported", path)
+		}
+		return k, nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported key type %T", path, key)
+	}
+}
+
+// verify checks the signature and the time claims of a token and returns its claims
+func (v *jwtVerifier) verify(token string, now time.Time) (*identityClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %w", err)
+	}
+
+	err = v.verifySignature(header.Alg, parts[0]+"."+parts[1], signature)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims identityClaims
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, errors.New("token expired")
+	}
+	if claims.NotBefore != 0 && now.Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, errors.New("token not valid yet")
+	}
+	return &claims, nil
+}
+
+// verifySignature checks the signature with the key matching the algorithm.
+// The algorithm must match the configured key, so "none" and key confusion are rejected.
+func (v *jwtVerifier) verifySignature(alg string, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+
+	switch alg {
+	case "HS256":
+		if len(v.secret) == 0 {
+			return errors.New("HS256 tokens need a shared secret")
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	case "RS256":
+		key, ok := v.publicKey.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("RS256 tokens need an RSA public key")
+		}
+		err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+		if err != nil {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	case "ES256":
+		key, ok := v.publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("ES256 tokens need an ECDSA public key")
+		}
+		// JWS encodes ES256 signatures as the fixed size concatenation of r and s
+		if len(signature) != 64 {
+			return errors.New("invalid token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(key, digest[:], r, s) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	content, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
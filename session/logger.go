@@ -0,0 +1,33 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+const loggerKey contextKey = "logger"
+
+// WithLogger returns a copy of ctx carrying the logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// Logger returns the logger of the request set by LoggerMiddleware, or slog.Default() outside of requests.
+// Log with the Context methods (InfoContext, ErrorContext...) so the request attributes are added.
+func Logger(ctx context.Context) *slog.Logger {
+	logger, ok := ctx.Value(loggerKey).(*slog.Logger)
+	if ok && logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// LoggerMiddleware stores the logger in the context of every request, see Logger
+func LoggerMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithLogger(r.Context(), logger)))
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerMiddleware(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+
+	var seen *slog.Logger
+	handler := LoggerMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = Logger(r.Context())
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Same(t, logger, seen)
+
+	// Outside of requests the default logger is used
+	assert.Same(t, slog.Default(), Logger(context.Background()))
+}
@@ -13,16 +13,22 @@ import (
 // Session data is stored as raw JSON and parsed lazily only when GetUserData() is called.
 func StrictInjectHTTPRequestMiddleware(next strictnethttp.StrictHTTPHandlerFunc, operationName string) strictnethttp.StrictHTTPHandlerFunc {
 	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
-		// Generate or extract request ID for tracing
-		requestID := r.Header.Get("X-Request-ID")
+		// Reuse the ID of RequestIDMiddleware, or generate one when it did not run
+		requestID := RequestIDFromContext(ctx)
 		if requestID == "" {
+			requestID = r.Header.Get(HeaderRequestID)
+		}
+		if !validRequestID(requestID) {
 			requestID = uuid.New().String()
 		}
+		// Echo the ID so clients and error responses can refer to it
+		w.Header().Set(HeaderRequestID, requestID)
 
 		// Create request context with raw data (lazy parsing)
 		reqCtx := &RequestContext{
 			RawRequest:  r,
 			RequestID:   requestID,
+			Operation:   operationName,
 			RequestTime: time.Now(),
 		}
 
@@ -35,21 +41,11 @@ func StrictInjectHTTPRequestMiddleware(next strictnethttp.StrictHTTPHandlerFunc,
 		// Store raw session data for lazy parsing
 		reqCtx.UserDataRaw = r.Header.Get(HeaderUserData)
 
-		// Store the complete context
+		// Store the complete context, and share it with the middlewares tracking it
 		ctx = context.WithValue(ctx, requestContextKey, reqCtx)
-		return next(ctx, w, r, request)
-	}
-}
-
-// StrictCORSMiddleware allows any domain for CORS requests.
-func StrictCORSMiddleware(next strictnethttp.StrictHTTPHandlerFunc, operationName string) strictnethttp.StrictHTTPHandlerFunc {
-	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return nil, nil
+		holder, ok := ctx.Value(requestContextHolderKey).(*requestContextHolder)
+		if ok {
+			holder.reqCtx = reqCtx
 		}
 		return next(ctx, w, r, request)
 	}
@@ -145,6 +145,7 @@ func TestStrictInjectHTTPRequestMiddleware(t *testing.T) {
 		reqCtx, err := GetRequestContext(capturedCtx)
 		assert.NoError(t, err)
 		assert.Equal(t, "existing-req-id-123", reqCtx.RequestID)
+		assert.Equal(t, "existing-req-id-123", w.Header().Get(HeaderRequestID), "Request ID should be echoed")
 	})
 
 	t.Run("InjectRequestGeneratesRequestID", func(t *testing.T) {
@@ -173,6 +174,7 @@ func TestStrictInjectHTTPRequestMiddleware(t *testing.T) {
 		assert.NotEmpty(t, reqCtx.RequestID)
 		// Should be a valid UUID format
 		assert.Len(t, reqCtx.RequestID, 36) // UUID length with dashes
+		assert.Equal(t, reqCtx.RequestID, w.Header().Get(HeaderRequestID))
 	})
 
 	t.Run("InjectRequestWithInvalidJSON", func(t *testing.T) {
@@ -233,59 +235,26 @@ func TestStrictInjectHTTPRequestMiddleware(t *testing.T) {
 		assert.True(t, reqCtx.RequestTime.After(beforeTest) || reqCtx.RequestTime.Equal(beforeTest))
 		assert.True(t, reqCtx.RequestTime.Before(afterTest) || reqCtx.RequestTime.Equal(afterTest))
 	})
-}
 
-func TestStrictCORSMiddleware(t *testing.T) {
-	t.Run("SetsCORSHeaders", func(t *testing.T) {
-		// Setup
+	t.Run("TrackRequestContext", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/api/test", nil)
+		req.Header.Set(HeaderUserId, "user123")
 
-		nextCalled := false
-		next := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
-			nextCalled = true
-			return "response", nil
-		}
-
-		middleware := StrictCORSMiddleware(next, "testOperation")
-
-		// Execute
-		w := httptest.NewRecorder()
-		result, err := middleware(context.Background(), w, req, nil)
-
-		// Assert
-		assert.NoError(t, err)
-		assert.Equal(t, "response", result)
-		assert.True(t, nextCalled)
-
-		// Check CORS headers
-		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
-		assert.Equal(t, "GET, POST, PUT, DELETE, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
-		assert.Equal(t, "Content-Type, Authorization", w.Header().Get("Access-Control-Allow-Headers"))
-	})
-
-	t.Run("HandlesOPTIONSRequest", func(t *testing.T) {
-		// Setup
-		req := httptest.NewRequest("OPTIONS", "/api/test", nil)
+		ctx, requestContext := TrackRequestContext(context.Background())
+		assert.Nil(t, requestContext())
+		// A second middleware tracking the request shares it
+		ctx, innerRequestContext := TrackRequestContext(ctx)
 
-		nextCalled := false
 		next := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
-			nextCalled = true
-			return "response", nil
+			return nil, nil
 		}
-
-		middleware := StrictCORSMiddleware(next, "testOperation")
-
-		// Execute
-		w := httptest.NewRecorder()
-		result, err := middleware(context.Background(), w, req, nil)
-
-		// Assert
+		_, err := StrictInjectHTTPRequestMiddleware(next, "GetUser")(ctx, httptest.NewRecorder(), req, nil)
 		assert.NoError(t, err)
-		assert.Nil(t, result)
-		assert.False(t, nextCalled, "Next handler should not be called for OPTIONS")
-		assert.Equal(t, http.StatusNoContent, w.Code)
 
-		// Check CORS headers are still set
-		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		reqCtx := requestContext()
+		assert.NotNil(t, reqCtx)
+		assert.Equal(t, "user123", reqCtx.UserID)
+		assert.Equal(t, "GetUser", reqCtx.Operation)
+		assert.Same(t, reqCtx, innerRequestContext())
 	})
 }
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	client "github.com/jmaister/taronja-gateway-clients/go"
+)
+
+// maxRequestIDLength bounds request IDs received from clients, longer ones are replaced
+const maxRequestIDLength = 128
+
+const requestIDKey contextKey = "requestID"
+
+// RequestIDMiddleware gives every request an ID, the X-Request-ID header of the request when it is
+// valid or a new UUID. The ID is returned in the X-Request-ID response header and stored in the
+// context, see RequestIDFromContext. It must wrap every other handler, so SPA and error responses have it too.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(HeaderRequestID)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+			r.Header.Set(HeaderRequestID, requestID)
+		}
+		w.Header().Set(HeaderRequestID, requestID)
+
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID accepts IDs of printable ASCII characters that are safe to log and echo
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDFromContext returns the ID set by RequestIDMiddleware, or the one of the
+// RequestContext when the middleware did not run, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, ok := ctx.Value(requestIDKey).(string)
+	if ok {
+		return requestID
+	}
+	reqCtx, ok := ctx.Value(requestContextKey).(*RequestContext)
+	if ok && reqCtx != nil {
+		return reqCtx.RequestID
+	}
+	return ""
+}
+
+// ForwardRequestID is a RequestEditorFn that sends the request ID to the gateway,
+// so a request can be traced across both services
+var ForwardRequestID client.RequestEditorFn = func(ctx context.Context, req *http.Request) error {
+	requestID := RequestIDFromContext(ctx)
+	if requestID != "" {
+		req.Header.Set(HeaderRequestID, requestID)
+	}
+	return nil
+}
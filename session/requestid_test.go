@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	t.Run("KeepsValidID", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(HeaderRequestID, "req-123")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, "req-123", seen)
+		assert.Equal(t, "req-123", w.Header().Get(HeaderRequestID))
+	})
+
+	t.Run("GeneratesMissingID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Len(t, seen, 36, "Should be a UUID")
+		assert.Equal(t, seen, w.Header().Get(HeaderRequestID))
+	})
+
+	t.Run("ReplacesInvalidID", func(t *testing.T) {
+		for _, invalid := range []string{strings.Repeat("a", maxRequestIDLength+1), "with space", "new\nline"} {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(HeaderRequestID, invalid)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.NotEqual(t, invalid, seen)
+			assert.Len(t, seen, 36)
+		}
+	})
+
+	t.Run("SharedWithStrictMiddleware", func(t *testing.T) {
+		var reqCtx *RequestContext
+		next := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+			reqCtx, _ = GetRequestContext(ctx)
+			return nil, nil
+		}
+		strict := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			StrictInjectHTTPRequestMiddleware(next, "GetMe")(r.Context(), w, r, nil)
+		}))
+
+		w := httptest.NewRecorder()
+		strict.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/me", nil))
+		assert.Equal(t, w.Header().Get(HeaderRequestID), reqCtx.RequestID)
+	})
+}
+
+func TestForwardRequestID(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey, "req-123")
+	req := httptest.NewRequest(http.MethodGet, "http://gateway/_/me", nil)
+
+	err := ForwardRequestID(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "req-123", req.Header.Get(HeaderRequestID))
+
+	// Without an ID nothing is sent
+	req = httptest.NewRequest(http.MethodGet, "http://gateway/_/me", nil)
+	err = ForwardRequestID(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Empty(t, req.Header.Get(HeaderRequestID))
+}
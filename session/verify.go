@@ -0,0 +1,213 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmaister/gots-template/config"
+)
+
+const (
+	// HeaderUserSignature carries the HMAC of the identity headers, "t=<unix seconds>,v2=<hex HMAC-SHA256>"
+	HeaderUserSignature = "X-User-Signature"
+	// HeaderUserToken carries a JWT whose claims cover the identity headers
+	HeaderUserToken = "X-User-Token"
+)
+
+// MaxSignatureAge bounds how long a captured signature or token can be replayed
+const MaxSignatureAge = 5 * time.Minute
+
+// ErrUntrustedIdentity is returned when identity headers fail verification
+var ErrUntrustedIdentity = fmt.Errorf("%w: identity headers could not be verified", ErrUnauthenticated)
+
+// HeaderVerifier checks that the X-User-Id and X-User-Data headers were set by the gateway
+// before anything reads them. Without it, anyone reaching the server directly can impersonate any user.
+type HeaderVerifier struct {
+	mode           string
+	secret         []byte
+	jwt            *jwtVerifier
+	trustedProxies []netip.Prefix
+	strip          bool
+
+	// OnReject writes the response of rejected requests, a plain 401 when nil
+	OnReject func(w http.ResponseWriter, r *http.Request, err error)
+	// now returns the current time, replaced in tests
+	now func() time.Time
+}
+
+// NewHeaderVerifier creates the verifier configured in the session settings
+func NewHeaderVerifier(cfg config.SessionConfig) (*HeaderVerifier, error) {
+	v := &HeaderVerifier{
+		mode:   cfg.HeaderVerification,
+		secret: []byte(cfg.HeaderSecret),
+		strip:  cfg.HeaderFailure == config.HeaderFailureStrip,
+		now:    time.Now,
+	}
+
+	for _, proxy := range cfg.TrustedProxies {
+		prefix, err := config.ParseTrustedProxy(proxy)
+		if err != nil {
+			return nil, err
+		}
+		v.trustedProxies = append(v.trustedProxies, prefix)
+	}
+
+	if v.mode == config.HeaderVerificationJWT {
+		v.jwt = &jwtVerifier{secret: v.secret}
+		if cfg.HeaderPublicKeyFile != "" {
+			key, err := loadPublicKey(cfg.HeaderPublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("error loading header public key: %w", err)
+			}
+			v.jwt.publicKey = key
+		}
+	}
+	return v, nil
+}
+
+// Enabled reports whether the verifier checks anything
+func (v *HeaderVerifier) Enabled() bool {
+	return v.mode != config.HeaderVerificationOff || len(v.trustedProxies) > 0
+}
+
+// Verify checks the identity headers of a request. Requests without them are anonymous and pass.
+func (v *HeaderVerifier) Verify(r *http.Request) error {
+	userID := r.Header.Get(HeaderUserId)
+	userData := r.Header.Get(HeaderUserData)
+	if userID == "" && userData == "" {
+		return nil
+	}
+
+	if len(v.trustedProxies) > 0 && !v.fromTrustedProxy(r) {
+		return fmt.Errorf("%w: %s is not a trusted proxy", ErrUntrustedIdentity, r.RemoteAddr)
+	}
+
+	var err error
+	switch v.mode {
+	case config.HeaderVerificationHMAC:
+		err = v.verifySignature(r.Header.Get(HeaderUserSignature), userID, userData)
+	case config.HeaderVerificationJWT:
+		err = v.verifyToken(r.Header.Get(HeaderUserToken), userID, userData)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUntrustedIdentity, err)
+	}
+	return nil
+}
+
+func (v *HeaderVerifier) fromTrustedProxy(r *http.Request) bool {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range v.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *HeaderVerifier) verifySignature(header string, userID string, userData string) error {
+	if header == "" {
+		return errors.New("missing " + HeaderUserSignature)
+	}
+
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v2":
+			signature = value
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signature == "" {
+		return errors.New("malformed " + HeaderUserSignature)
+	}
+
+	signedAt := time.Unix(seconds, 0)
+	age := v.now().Sub(signedAt)
+	if age > MaxSignatureAge || age < -MaxSignatureAge {
+		return errors.New("signature expired")
+	}
+
+	expected := SignIdentity(v.secret, userID, userData, signedAt)
+	if !hmac.Equal([]byte(header), []byte(expected)) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func (v *HeaderVerifier) verifyToken(token string, userID string, userData string) error {
+	if token == "" {
+		return errors.New("missing " + HeaderUserToken)
+	}
+
+	claims, err := v.jwt.verify(token, v.now())
+	if err != nil {
+		return err
+	}
+	if claims.Subject != userID {
+		return errors.New("token subject does not match " + HeaderUserId)
+	}
+	digest := sha256.Sum256([]byte(userData))
+	if !hmac.Equal([]byte(claims.UserDataSHA256), []byte(hex.EncodeToString(digest[:]))) {
+		return errors.New("token does not match " + HeaderUserData)
+	}
+	return nil
+}
+
+// SignIdentity returns the X-User-Signature value of the identity headers signed at a time.
+// The HMAC-SHA256 covers "<unix seconds>\n<base64 X-User-Id>\n<base64 X-User-Data>", with standard
+// padded base64, so no value can shift bytes into the next field.
+func SignIdentity(secret []byte, userID string, userData string, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "\n" + base64.StdEncoding.EncodeToString([]byte(userID)) + "\n" + base64.StdEncoding.EncodeToString([]byte(userData))))
+	return "t=" + timestamp + ",v2=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Middleware verifies the identity headers of every request. Requests that fail are rejected,
+// or with the strip failure action continue as anonymous requests without the identity headers.
+func (v *HeaderVerifier) Middleware(next http.Handler) http.Handler {
+	if !v.Enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := v.Verify(r)
+		if err == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		Logger(r.Context()).WarnContext(r.Context(), "Identity headers rejected", "method", r.Method, "path", r.URL.Path, "error", err)
+		if v.strip {
+			r = r.Clone(r.Context())
+			for _, header := range []string{HeaderUserId, HeaderUserData, HeaderUserSignature, HeaderUserToken} {
+				r.Header.Del(header)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if v.OnReject != nil {
+			v.OnReject(w, r, err)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
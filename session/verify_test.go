@@ -0,0 +1,260 @@
+package session
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testUserData = `{"UserID":"user-1","Username":"jane","IsAdmin":true}`
+
+// identityRequest returns a request carrying identity headers from a remote address
+func identityRequest(remoteAddr string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	r.RemoteAddr = remoteAddr
+	r.Header.Set(HeaderUserId, "user-1")
+	r.Header.Set(HeaderUserData, testUserData)
+	return r
+}
+
+func newTestVerifier(t *testing.T, cfg config.SessionConfig, now time.Time) *HeaderVerifier {
+	if cfg.HeaderVerification == "" {
+		cfg.HeaderVerification = config.HeaderVerificationOff
+	}
+	if cfg.HeaderFailure == "" {
+		cfg.HeaderFailure = config.HeaderFailureReject
+	}
+	v, err := NewHeaderVerifier(cfg)
+	assert.NoError(t, err)
+	v.now = func() time.Time { return now }
+	return v
+}
+
+// signToken builds a compact JWS with the identity claims
+func signToken(t *testing.T, alg string, key interface{}, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	assert.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	assert.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		assert.NoError(t, err)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func identityClaimsFor(userID string, userData string, expiresAt time.Time) map[string]interface{} {
+	digest := sha256.Sum256([]byte(userData))
+	return map[string]interface{}{
+		"sub":              userID,
+		"exp":              expiresAt.Unix(),
+		"user_data_sha256": hex.EncodeToString(digest[:]),
+	}
+}
+
+// writePublicKey writes the PEM public key of a private key to a temporary file
+func writePublicKey(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "gateway.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)
+	assert.NoError(t, err)
+	return path
+}
+
+func TestHeaderVerifierHMAC(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	secret := []byte("shared-secret")
+	v := newTestVerifier(t, config.SessionConfig{HeaderVerification: config.HeaderVerificationHMAC, HeaderSecret: string(secret)}, now)
+
+	t.Run("Valid", func(t *testing.T) {
+		r := identityRequest("10.0.0.1:1234")
+		r.Header.Set(HeaderUserSignature, SignIdentity(secret, "user-1", testUserData, now.Add(-time.Minute)))
+		assert.NoError(t, v.Verify(r))
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		assert.NoError(t, v.Verify(r))
+	})
+
+	t.Run("ShiftedFields", func(t *testing.T) {
+		// Moving bytes from one header to the other must change the signature
+		assert.NotEqual(t, SignIdentity(secret, "user-1.x", "y", now), SignIdentity(secret, "user-1", "x.y", now))
+		assert.NotEqual(t, SignIdentity(secret, "user-1\nx", "y", now), SignIdentity(secret, "user-1", "x\ny", now))
+	})
+
+	tests := []struct {
+		name      string
+		signature string
+	}{
+		{"Missing", ""},
+		{"Malformed", "v2=abc"},
+		{"WrongSecret", SignIdentity([]byte("other"), "user-1", testUserData, now)},
+		{"OtherUser", SignIdentity(secret, "user-2", testUserData, now)},
+		{"Expired", SignIdentity(secret, "user-1", testUserData, now.Add(-MaxSignatureAge-time.Second))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := identityRequest("10.0.0.1:1234")
+			r.Header.Set(HeaderUserSignature, tt.signature)
+			err := v.Verify(r)
+			assert.ErrorIs(t, err, ErrUntrustedIdentity)
+			assert.ErrorIs(t, err, ErrUnauthenticated)
+		})
+	}
+}
+
+func TestHeaderVerifierJWT(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	valid := identityClaimsFor("user-1", testUserData, now.Add(time.Minute))
+
+	t.Run("HS256", func(t *testing.T) {
+		secret := []byte("shared-secret")
+		v := newTestVerifier(t, config.SessionConfig{HeaderVerification: config.HeaderVerificationJWT, HeaderSecret: string(secret)}, now)
+
+		r := identityRequest("10.0.0.1:1234")
+		r.Header.Set(HeaderUserToken, signToken(t, "HS256", secret, valid))
+		assert.NoError(t, v.Verify(r))
+
+		r.Header.Set(HeaderUserToken, signToken(t, "HS256", []byte("other"), valid))
+		assert.ErrorIs(t, v.Verify(r), ErrUntrustedIdentity)
+	})
+
+	t.Run("ES256", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		v := newTestVerifier(t, config.SessionConfig{HeaderVerification: config.HeaderVerificationJWT, HeaderPublicKeyFile: writePublicKey(t, &key.PublicKey)}, now)
+
+		r := identityRequest("10.0.0.1:1234")
+		r.Header.Set(HeaderUserToken, signToken(t, "ES256", key, valid))
+		assert.NoError(t, v.Verify(r))
+
+		// Without a shared secret, HS256 tokens cannot be verified
+		r.Header.Set(HeaderUserToken, signToken(t, "HS256", []byte(""), valid))
+		assert.ErrorIs(t, v.Verify(r), ErrUntrustedIdentity)
+	})
+
+	t.Run("RS256", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+		v := newTestVerifier(t, config.SessionConfig{HeaderVerification: config.HeaderVerificationJWT, HeaderPublicKeyFile: writePublicKey(t, &key.PublicKey)}, now)
+
+		r := identityRequest("10.0.0.1:1234")
+		r.Header.Set(HeaderUserToken, signToken(t, "RS256", key, valid))
+		assert.NoError(t, v.Verify(r))
+	})
+
+	t.Run("Claims", func(t *testing.T) {
+		secret := []byte("shared-secret")
+		v := newTestVerifier(t, config.SessionConfig{HeaderVerification: config.HeaderVerificationJWT, HeaderSecret: string(secret)}, now)
+
+		tests := []struct {
+			name   string
+			claims map[string]interface{}
+		}{
+			{"Expired", identityClaimsFor("user-1", testUserData, now.Add(-time.Second))},
+			{"OtherUser", identityClaimsFor("user-2", testUserData, now.Add(time.Minute))},
+			{"OtherUserData", identityClaimsFor("user-1", `{"IsAdmin":false}`, now.Add(time.Minute))},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				r := identityRequest("10.0.0.1:1234")
+				r.Header.Set(HeaderUserToken, signToken(t, "HS256", secret, tt.claims))
+				assert.ErrorIs(t, v.Verify(r), ErrUntrustedIdentity)
+			})
+		}
+	})
+
+	t.Run("NoneAlgorithm", func(t *testing.T) {
+		v := newTestVerifier(t, config.SessionConfig{HeaderVerification: config.HeaderVerificationJWT, HeaderSecret: "shared-secret"}, now)
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+		payload, err := json.Marshal(valid)
+		assert.NoError(t, err)
+
+		r := identityRequest("10.0.0.1:1234")
+		r.Header.Set(HeaderUserToken, header+"."+base64.RawURLEncoding.EncodeToString(payload)+".")
+		assert.ErrorIs(t, v.Verify(r), ErrUntrustedIdentity)
+	})
+}
+
+func TestHeaderVerifierTrustedProxies(t *testing.T) {
+	v := newTestVerifier(t, config.SessionConfig{TrustedProxies: []string{"10.0.0.0/8", "::1"}}, time.Now())
+
+	assert.NoError(t, v.Verify(identityRequest("10.1.2.3:1234")))
+	assert.NoError(t, v.Verify(identityRequest("[::1]:1234")))
+	assert.ErrorIs(t, v.Verify(identityRequest("192.168.1.10:1234")), ErrUntrustedIdentity)
+}
+
+func TestHeaderVerifierMiddleware(t *testing.T) {
+	var seenUserID string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenUserID = r.Header.Get(HeaderUserId)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		v := newTestVerifier(t, config.SessionConfig{}, time.Now())
+		assert.False(t, v.Enabled())
+
+		w := httptest.NewRecorder()
+		v.Middleware(next).ServeHTTP(w, identityRequest("192.168.1.10:1234"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "user-1", seenUserID)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		seenUserID = ""
+		v := newTestVerifier(t, config.SessionConfig{TrustedProxies: []string{"127.0.0.1"}}, time.Now())
+
+		w := httptest.NewRecorder()
+		v.Middleware(next).ServeHTTP(w, identityRequest("192.168.1.10:1234"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, seenUserID, "The handler must not run")
+	})
+
+	t.Run("Strip", func(t *testing.T) {
+		v := newTestVerifier(t, config.SessionConfig{TrustedProxies: []string{"127.0.0.1"}, HeaderFailure: config.HeaderFailureStrip}, time.Now())
+
+		r := identityRequest("192.168.1.10:1234")
+		w := httptest.NewRecorder()
+		v.Middleware(next).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, seenUserID, "The request continues as anonymous")
+		assert.Equal(t, "user-1", r.Header.Get(HeaderUserId), "The original request is not modified")
+	})
+}
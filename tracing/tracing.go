@@ -0,0 +1,169 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/jmaister/gots-template/session"
+	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// ScopeName is the instrumentation scope of the spans created by the application
+const ScopeName = "github.com/jmaister/gots-template"
+
+// propagator reads and writes the W3C traceparent and tracestate headers
+var propagator = propagation.TraceContext{}
+
+// New creates the tracer provider configured in cfg, a no-op provider when tracing is disabled.
+// Call the returned shutdown function on exit to flush the spans not exported yet.
+func New(ctx context.Context, cfg config.TracingConfig) (trace.TracerProvider, func(context.Context) error, error) {
+	if cfg.Exporter == config.TracingExporterNone {
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closer, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The configured service name wins over OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
+	)
+	if err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("error creating trace resource: %w", err), closer.Close())
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	shutdown := func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		return errors.Join(err, closer.Close())
+	}
+	return provider, shutdown, nil
+}
+
+// newExporter creates the span exporter of cfg and the closer of the file it writes to, if any
+func newExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, io.Closer, error) {
+	switch cfg.Exporter {
+	case config.TracingExporterOTLP:
+		var options []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err := otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error creating OTLP trace exporter: %w", err)
+		}
+		return exporter, nopCloser{}, nil
+
+	case config.TracingExporterStdout:
+		var w io.Writer = os.Stdout
+		var closer io.Closer = nopCloser{}
+		if cfg.File != "" {
+			file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error opening trace file: %w", err)
+			}
+			w = file
+			closer = file
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, nil, errors.Join(fmt.Errorf("error creating stdout trace exporter: %w", err), closer.Close())
+		}
+		return exporter, closer, nil
+	}
+	return nil, nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+}
+
+// Start starts a child span of the span in ctx, with the tracer provider of that span.
+// Without a span in ctx, e.g. when tracing is disabled, the span is not recorded.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(ScopeName).Start(ctx, name, opts...)
+}
+
+// End records err, if any, as the error of span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Middleware returns a net/http middleware starting a server span for every request, continuing
+// the trace of the W3C traceparent header when present. The strict middleware renames the span
+// of API requests to their operation.
+func Middleware(tp trace.TracerProvider) func(http.Handler) http.Handler {
+	tracer := tp.Tracer(ScopeName)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			recorder := session.NewResponseRecorder(w)
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			status := recorder.Status()
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			// Client errors are not errors of the server
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
+
+// StrictMiddleware names the request span after the OpenAPI operation and records the error
+// returned by the handler. The status of the span follows the response status.
+func StrictMiddleware(next strictnethttp.StrictHTTPHandlerFunc, operationName string) strictnethttp.StrictHTTPHandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		span := trace.SpanFromContext(ctx)
+		span.SetName(operationName)
+
+		response, err := next(ctx, w, r, request)
+		if err != nil {
+			span.RecordError(err)
+		}
+		return response, err
+	}
+}
+
+// PropagateTraceContext is a client.RequestEditorFn sending the trace of ctx to the gateway
+// in the W3C traceparent header
+func PropagateTraceContext(ctx context.Context, req *http.Request) error {
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmaister/gots-template/config"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	strict := StrictMiddleware(func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, errors.New("boom")
+	}, "GetUser")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users/1", func(w http.ResponseWriter, r *http.Request) {
+		_, err := strict(r.Context(), w, r, nil)
+		assert.Error(t, err)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := Middleware(provider)(mux)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+	r.Header.Set("traceparent", traceparent)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	api, spa := spans[0], spans[1]
+
+	assert.Equal(t, "GetUser", api.Name(), "API spans are named after the operation")
+	assert.Equal(t, trace.SpanKindServer, api.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", api.SpanContext().TraceID().String(), "The trace of traceparent continues")
+	assert.Equal(t, "00f067aa0ba902b7", api.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, api.Status().Code)
+	assert.Len(t, api.Events(), 1, "The handler error is recorded")
+
+	assert.Equal(t, "GET", spa.Name())
+	assert.False(t, spa.Parent().IsValid(), "A new trace starts without traceparent")
+	assert.Equal(t, codes.Unset, spa.Status().Code, "Client errors are not span errors")
+}
+
+func TestPropagateTraceContext(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "GetMe")
+	defer span.End()
+
+	r := httptest.NewRequest(http.MethodGet, "/_/me", nil)
+	assert.NoError(t, PropagateTraceContext(ctx, r))
+	assert.Regexp(t, "^00-"+span.SpanContext().TraceID().String()+"-"+span.SpanContext().SpanID().String()+"-01$", r.Header.Get("traceparent"))
+
+	r = httptest.NewRequest(http.MethodGet, "/_/me", nil)
+	assert.NoError(t, PropagateTraceContext(context.Background(), r))
+	assert.Empty(t, r.Header.Get("traceparent"), "Nothing is sent outside a trace")
+}
+
+func TestNew(t *testing.T) {
+	t.Run("None", func(t *testing.T) {
+		provider, shutdown, err := New(context.Background(), config.TracingConfig{Exporter: config.TracingExporterNone})
+		assert.NoError(t, err)
+		_, span := provider.Tracer("test").Start(context.Background(), "Ignored")
+		assert.False(t, span.IsRecording())
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("StdoutFile", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "traces.json")
+		provider, shutdown, err := New(context.Background(), config.TracingConfig{
+			Exporter:    config.TracingExporterStdout,
+			File:        path,
+			ServiceName: "test-service",
+		})
+		assert.NoError(t, err)
+
+		ctx, parent := provider.Tracer("test").Start(context.Background(), "GetUser")
+		_, child := Start(ctx, "GetCurrentUser")
+		End(child, errors.New("unreachable"))
+		parent.End()
+		assert.NoError(t, shutdown(context.Background()), "Shutdown flushes the spans")
+
+		file, err := os.Open(path)
+		assert.NoError(t, err)
+		defer file.Close()
+		decoder := json.NewDecoder(file)
+		var spans []map[string]interface{}
+		for decoder.More() {
+			var span map[string]interface{}
+			assert.NoError(t, decoder.Decode(&span))
+			spans = append(spans, span)
+		}
+		assert.Len(t, spans, 2)
+		assert.Equal(t, "GetCurrentUser", spans[0]["Name"])
+		assert.Equal(t, "GetUser", spans[1]["Name"])
+		assert.Contains(t, spans[1]["Resource"], map[string]interface{}{
+			"Key":   "service.name",
+			"Value": map[string]interface{}{"Type": "STRING", "Value": "test-service"},
+		})
+	})
+
+	t.Run("OTLP", func(t *testing.T) {
+		_, shutdown, err := New(context.Background(), config.TracingConfig{
+			Exporter:    config.TracingExporterOTLP,
+			Endpoint:    "http://127.0.0.1:1/v1/traces",
+			ServiceName: "test-service",
+		})
+		assert.NoError(t, err, "The collector is not reached before spans are exported")
+		assert.NoError(t, shutdown(context.Background()))
+	})
+}
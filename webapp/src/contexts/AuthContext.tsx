@@ -1,9 +1,9 @@
 import { createContext, useContext, useState, useEffect, useRef, type ReactNode } from 'react';
 
-// User Interface based on the API response from /me endpoint
-// TODO: Generate using openapi-generator-cli or similar tool
+// User Interface based on the CurrentUser schema of the /api/me endpoint (api/openapi-spec.yaml)
 export interface User {
     authenticated: boolean;
+    userId: string;
     username: string;
     email?: string;
     name?: string;
@@ -11,7 +11,8 @@ export interface User {
     givenName?: string;
     familyName?: string;
     provider?: string;
-    timestamp: string;
+    sessionValidUntil?: string;
+    timestamp?: string;
     isAdmin: boolean;
 }
 
@@ -141,7 +142,7 @@ export const useAuth = (): AuthContextType => {
  */
 export const fetchMe = async (): Promise<User | null> => {
     try {
-        const response = await fetch('/_/me', {
+        const response = await fetch('/api/me', {
             method: 'GET',
             credentials: 'include', // Changed from 'same-origin' to 'include' for better cross-origin support
             headers: {
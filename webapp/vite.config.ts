@@ -1,16 +1,55 @@
 /// <reference types="vitest" />
-import { defineConfig } from 'vite';
+import { defineConfig, type Plugin } from 'vite';
 import react from '@vitejs/plugin-react';
 import tailwindcss from '@tailwindcss/vite';
 import { visualizer } from 'rollup-plugin-visualizer';
+import { readdirSync, readFileSync, writeFileSync } from 'node:fs';
+import { extname, join, resolve } from 'node:path';
+import { brotliCompressSync, constants, gzipSync } from 'node:zlib';
 
-
+// Writes .br and .gz siblings of the compressible files of the build, the Go server sends them to the
+// clients accepting those encodings instead of compressing the files on every request
+function precompress(minSize = 1024): Plugin {
+    const extensions = new Set(['.html', '.js', '.mjs', '.css', '.json', '.svg', '.txt', '.xml', '.webmanifest', '.wasm']);
+    let outDir = 'dist';
+    return {
+        name: 'precompress',
+        apply: 'build',
+        configResolved(config) {
+            outDir = resolve(config.root, config.build.outDir);
+        },
+        closeBundle() {
+            for (const entry of readdirSync(outDir, { recursive: true, withFileTypes: true })) {
+                const file = join(entry.parentPath, entry.name);
+                if (!entry.isFile() || !extensions.has(extname(file))) {
+                    continue;
+                }
+                const content = readFileSync(file);
+                if (content.length < minSize) {
+                    continue;
+                }
+                const brotli = brotliCompressSync(content, {
+                    params: { [constants.BROTLI_PARAM_QUALITY]: constants.BROTLI_MAX_QUALITY },
+                });
+                const gzip = gzipSync(content, { level: constants.Z_BEST_COMPRESSION });
+                // A sibling that is not smaller is useless
+                if (brotli.length < content.length) {
+                    writeFileSync(file + '.br', brotli);
+                }
+                if (gzip.length < content.length) {
+                    writeFileSync(file + '.gz', gzip);
+                }
+            }
+        },
+    };
+}
 
 // https://vite.dev/config/
 export default defineConfig(({ mode }) => ({
     plugins: [
         react(),
         tailwindcss(),
+        precompress(),
         // Visualizer plugin to analyze bundle size
         mode === 'analyze' && visualizer({
         open: true,